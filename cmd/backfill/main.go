@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"effective_mobile/internal/config"
+	"effective_mobile/internal/lib/dedup"
+	"effective_mobile/internal/lib/langdetect"
+	"effective_mobile/internal/storage"
+	"effective_mobile/internal/storage/postgres"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: backfill [flags] <command>
+
+commands:
+  language    detect the language of the lyrics of existing songs
+  duplicates  compute the name keys and lyrics fingerprints of existing songs
+
+Songs are processed in batches in ID order, so an interrupted run can be
+started again and only redoes the current batch.
+
+flags:
+`
+
+var errUsage = errors.New("usage error")
+
+func main() {
+	var batchSize int
+	var all bool
+
+	flag.IntVar(&batchSize, "batch", 500, "songs per batch")
+	flag.BoolVar(&all, "all", false, "redo songs that were already processed")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	os.Exit(run(flag.Args(), batchSize, all))
+}
+
+func run(args []string, batchSize int, all bool) int {
+	if len(args) != 1 {
+		flag.Usage()
+
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	switch args[0] {
+	case "language":
+		err = backfillLanguage(ctx, batchSize, all)
+	case "duplicates":
+		err = backfillDuplicates(ctx, batchSize, all)
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+
+		return exitError
+	}
+
+	return exitOK
+}
+
+func backfillLanguage(ctx context.Context, batchSize int, all bool) error {
+	db, err := open(batchSize)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var afterID, detected, undetermined int
+
+	for {
+		songs, err := db.LyricsBatch(ctx, afterID, batchSize, !all)
+		if err != nil {
+			return err
+		}
+
+		if len(songs) == 0 {
+			break
+		}
+
+		for _, song := range songs {
+			language := langdetect.Detect(song.Text)
+			err := db.SetLanguage(ctx, song.ID, language)
+			if errors.Is(err, storage.ErrSongNotFound) {
+				// Deleted since the batch was read.
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("song %d: %w", song.ID, err)
+			}
+
+			if language.Lang == "" {
+				undetermined++
+			} else {
+				detected++
+			}
+		}
+
+		afterID = songs[len(songs)-1].ID
+
+		fmt.Printf("processed songs up to id %d\n", afterID)
+	}
+
+	fmt.Printf("languages detected: %d, too short to tell: %d\n", detected, undetermined)
+
+	return nil
+}
+
+// backfillDuplicates computes the canonical keys of existing songs and the
+// fingerprints of their lyrics.
+func backfillDuplicates(ctx context.Context, batchSize int, all bool) error {
+	db, err := open(batchSize)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var afterID, processed int
+
+	for {
+		songs, err := db.SongsBatch(ctx, afterID, batchSize, !all)
+		if err != nil {
+			return err
+		}
+
+		if len(songs) == 0 {
+			break
+		}
+
+		for _, song := range songs {
+			fp, _ := dedup.Fingerprint(song.Text)
+			err := db.SetDuplicateKeys(ctx, song.ID, dedup.CanonicalKey(song.Group, song.Song), fp)
+			if errors.Is(err, storage.ErrSongNotFound) {
+				// Deleted since the batch was read.
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("song %d: %w", song.ID, err)
+			}
+
+			processed++
+		}
+
+		afterID = songs[len(songs)-1].ID
+
+		fmt.Printf("processed songs up to id %d\n", afterID)
+	}
+
+	fmt.Printf("songs keyed: %d\n", processed)
+
+	return nil
+}
+
+func open(batchSize int) (*postgres.Storage, error) {
+	if batchSize < 1 {
+		return nil, fmt.Errorf("%w: -batch must be positive", errUsage)
+	}
+
+	cfg := config.MustLoad()
+
+	return postgres.New(cfg.DB.Port, cfg.DB.Host, cfg.DB.User, cfg.DB.Name, cfg.DB.Password, cfg.DB.SSLMode)
+}
@@ -4,23 +4,97 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 
 	"effective_mobile/internal/config"
+	"effective_mobile/internal/migrator"
 )
 
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: migrator [flags] <command> [args]
+
+commands:
+  up [N]        apply all or N pending migrations
+  down [N]      roll back N applied migrations (default 1)
+  goto V        migrate up or down to version V
+  version       print the current version
+  force V       set the version to V (-1 for none) and clear the dirty flag without migrating
+  status        list applied and pending migrations
+  create NAME   scaffold timestamped up and down files for a new migration
+
+Migrations embedded into the binary are used unless -migrations-path is set.
+create always needs -migrations-path.
+
+flags:
+`
+
+var errUsage = errors.New("usage error")
+
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 func main() {
 	var migrationsPath, migrationsTable string
 
-	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations")
+	flag.StringVar(&migrationsPath, "migrations-path", "", "path to migrations (default: embedded migrations)")
 	flag.StringVar(&migrationsTable, "migrations-table", "migrations", "name of migrations table")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	if migrationsPath == "" {
-		panic("migrations-path is required")
+	os.Exit(run(migrationsPath, migrationsTable, flag.Args()))
+}
+
+func run(migrationsPath, migrationsTable string, args []string) int {
+	if len(args) == 0 {
+		flag.Usage()
+
+		return exitUsage
+	}
+
+	command, args := args[0], args[1:]
+
+	var err error
+	if command == "create" {
+		err = create(migrationsPath, args)
+	} else {
+		err = migrateCommand(migrationsPath, migrationsTable, command, args)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+
+		return exitError
+	}
+
+	return exitOK
+}
+
+func migrateCommand(migrationsPath, migrationsTable, command string, args []string) error {
+	switch command {
+	case "up", "down", "goto", "version", "force", "status":
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
 	}
 
 	cfg := config.MustLoad()
@@ -30,23 +104,228 @@ func main() {
 		cfg.DB.User, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Name, cfg.DB.SSLMode, migrationsTable,
 	)
 
-	m, err := migrate.New(
-		"file://"+migrationsPath,
-		dsn,
-	)
+	src, sourceName, err := openSource(migrationsPath)
 	if err != nil {
-		panic(err)
+		return err
+	}
+
+	m, err := migrate.NewWithSourceInstance(sourceName, src, dsn)
+	if err != nil {
+		src.Close()
+
+		return err
 	}
+	defer m.Close()
+
+	switch command {
+	case "up":
+		return up(m, args)
+	case "down":
+		return down(m, args)
+	case "goto":
+		return gotoVersion(m, args)
+	case "version":
+		return version(m)
+	case "force":
+		return force(m, args)
+	default:
+		return status(m, migrationsPath)
+	}
+}
+
+func up(m *migrate.Migrate, args []string) error {
+	n, err := optionalCount(args, 0)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		err = m.Up()
+	} else {
+		err = m.Steps(n)
+	}
+
+	return reportChange(err, "migrations applied")
+}
+
+func down(m *migrate.Migrate, args []string) error {
+	n, err := optionalCount(args, 1)
+	if err != nil {
+		return err
+	}
+
+	return reportChange(m.Steps(-n), "migrations rolled back")
+}
+
+func gotoVersion(m *migrate.Migrate, args []string) error {
+	v, err := requiredVersion(args)
+	if err != nil {
+		return err
+	}
+
+	if v < 0 {
+		return fmt.Errorf("%w: goto expects a non-negative version", errUsage)
+	}
+
+	return reportChange(m.Migrate(uint(v)), fmt.Sprintf("migrated to version %d", v))
+}
+
+func force(m *migrate.Migrate, args []string) error {
+	v, err := requiredVersion(args)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Force(v); err != nil {
+		return err
+	}
+
+	fmt.Printf("version forced to %d\n", v)
+
+	return nil
+}
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			fmt.Println("no migrations to apply")
+func version(m *migrate.Migrate) error {
+	v, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Println("no migrations applied")
 
-			return
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Printf("%d (dirty)\n", v)
+	} else {
+		fmt.Println(v)
+	}
+
+	return nil
+}
+
+func status(m *migrate.Migrate, migrationsPath string) error {
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	applied := err == nil
+
+	src, _, err := openSource(migrationsPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	v, err := src.First()
+	for err == nil {
+		state := "pending"
+		if applied && v <= current {
+			state = "applied"
+			if dirty && v == current {
+				state = "dirty"
+			}
+		}
+
+		name := strconv.FormatUint(uint64(v), 10)
+		if r, identifier, readErr := src.ReadUp(v); readErr == nil {
+			r.Close()
+			name = fmt.Sprintf("%d_%s", v, identifier)
+		}
+
+		fmt.Printf("%-8s %s\n", state, name)
+
+		v, err = src.Next(v)
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// openSource opens the migrations at migrationsPath, or the embedded ones
+// when it is empty.
+func openSource(migrationsPath string) (source.Driver, string, error) {
+	if migrationsPath == "" {
+		src, err := migrator.Source()
+
+		return src, "iofs", err
+	}
+
+	src, err := source.Open("file://" + migrationsPath)
+
+	return src, "file", err
+}
+
+func create(migrationsPath string, args []string) error {
+	if migrationsPath == "" {
+		return fmt.Errorf("%w: create needs -migrations-path", errUsage)
+	}
+
+	if len(args) != 1 || !migrationName.MatchString(args[0]) {
+		return fmt.Errorf("%w: create expects one NAME of lowercase letters, digits and underscores", errUsage)
+	}
+
+	base := fmt.Sprintf("%s_%s", time.Now().UTC().Format("20060102150405"), args[0])
+
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(migrationsPath, fmt.Sprintf("%s.%s.sql", base, direction))
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
 		}
 
-		panic(err)
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		fmt.Println(path)
+	}
+
+	return nil
+}
+
+func reportChange(err error, message string) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no migrations to apply")
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(message)
+
+	return nil
+}
+
+func optionalCount(args []string, defaultValue int) (int, error) {
+	if len(args) == 0 {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || len(args) > 1 {
+		return 0, fmt.Errorf("%w: N must be a positive integer", errUsage)
+	}
+
+	return n, nil
+}
+
+func requiredVersion(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("%w: expected a single version argument", errUsage)
+	}
+
+	v, err := strconv.Atoi(args[0])
+	if err != nil || v < -1 {
+		return 0, fmt.Errorf("%w: version must be a non-negative integer or -1 for no version", errUsage)
 	}
 
-	fmt.Println("migrations applied")
+	return v, nil
 }
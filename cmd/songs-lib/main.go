@@ -10,32 +10,45 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
-
 	"effective_mobile/internal/clients/external"
 	"effective_mobile/internal/config"
-	deletehandler "effective_mobile/internal/http-server/handlers/song/delete"
-	filterhandler "effective_mobile/internal/http-server/handlers/song/filter"
-	savehandler "effective_mobile/internal/http-server/handlers/song/save"
-	texthandler "effective_mobile/internal/http-server/handlers/song/text"
-	updatehandler "effective_mobile/internal/http-server/handlers/song/update"
-	"effective_mobile/internal/http-server/middleware/logger"
+	"effective_mobile/internal/domain/models"
+	ratelimitmiddleware "effective_mobile/internal/http-server/middleware/ratelimit"
+	"effective_mobile/internal/lib/health"
+	"effective_mobile/internal/lib/logger/redact"
 	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/metrics"
+	"effective_mobile/internal/lib/ratelimit"
+	"effective_mobile/internal/lib/rbac"
+	"effective_mobile/internal/lib/similar"
+	"effective_mobile/internal/lib/suggest"
+	"effective_mobile/internal/lib/tokens"
+	"effective_mobile/internal/lib/tracing"
+	"effective_mobile/internal/lib/views"
+	"effective_mobile/internal/migrator"
+	apikeyservice "effective_mobile/internal/service/apikey-service"
+	authservice "effective_mobile/internal/service/auth-service"
 	songservice "effective_mobile/internal/service/song-service"
 	"effective_mobile/internal/storage/postgres"
 )
 
+const authRealm = "songs-service"
+
 const (
 	envLocal = "local"
 	envDev   = "dev"
 	envProd  = "prod"
 )
 
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 func main() {
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	log, logLevel := setupLogger(cfg.Env, cfg.Log)
 
 	log.Info(
 		"starting songs-lib",
@@ -43,32 +56,99 @@ func main() {
 	)
 	log.Debug("debug messages are enabled")
 
+	shutdownTracing, err := tracing.Setup(
+		context.Background(),
+		cfg.Tracing.ServiceName,
+		cfg.Tracing.Exporter,
+		cfg.Tracing.File,
+		cfg.Tracing.SampleRatio,
+	)
+	if err != nil {
+		panic(err)
+	}
+
 	storage, err := postgres.New(cfg.DB.Port, cfg.DB.Host, cfg.DB.User, cfg.DB.Name, cfg.DB.Password, cfg.DB.SSLMode)
 	if err != nil {
 		panic(err)
 	}
 
-	client := external.New(log, cfg.ExternalAPI, cfg.HTTPServer.Timeout)
-	service := songservice.New(storage, storage, client)
+	if cfg.DB.AutoMigrate {
+		version, err := migrator.AutoMigrate(context.Background(), storage.DB(), cfg.DB.MigrationsTable)
+		if err != nil {
+			panic(err)
+		}
+
+		log.Info("database migrated", slog.Uint64("version", uint64(version)))
+	}
+
+	appMetrics := metrics.New(storage.DB())
+
+	client := external.New(log, cfg.ExternalAPI, cfg.HTTPServer.Timeout, appMetrics)
+	policy, err := rbac.LoadPolicy(cfg.RBAC.PolicyFile)
+	if err != nil {
+		panic(err)
+	}
+
+	suggestions := suggest.NewCache(cfg.Suggest.CacheSize, cfg.Suggest.CacheTTL)
+	viewCounter := views.NewCounter()
+	similarIndex := similar.New(similar.Weights{
+		Lyrics:     cfg.Similar.LyricsWeight,
+		Artist:     cfg.Similar.ArtistWeight,
+		Year:       cfg.Similar.YearWeight,
+		YearWindow: cfg.Similar.YearWindow,
+	})
+
+	service := songservice.New(storage, storage, storage, client, policy,
+		songservice.ChangeRecorders{appMetrics, suggestions}, suggestions, viewCounter, similarIndex)
+
+	keys, err := tokens.NewKeySet(cfg.JWT.SigningKID, cfg.JWT.HMACKeys, cfg.JWT.RSAKeys)
+	if err != nil {
+		panic(err)
+	}
+
+	go purgeExpired(log, storage, time.Hour)
 
-	router := chi.NewRouter()
+	viewsCtx, stopViews := context.WithCancel(context.Background())
+	go viewCounter.Run(viewsCtx, log, cfg.Suggest.ViewsFlushInterval, storage.AddViews)
 
-	router.Use(middleware.RequestID)
-	router.Use(logger.New(log))
-	router.Use(middleware.Recoverer)
+	go rebuildSimilar(log, similarIndex, storage, cfg.Similar.RebuildInterval)
 
-	router.Route("/songs", func(r chi.Router) {
-		r.Use(middleware.BasicAuth("songs-service", map[string]string{
-			cfg.HTTPServer.User: cfg.HTTPServer.Password,
-		}))
+	tokenManager := tokens.NewManager(keys, cfg.JWT.Issuer, cfg.JWT.AccessTTL)
+	authService := authservice.New(
+		setupUsers(cfg),
+		tokenManager,
+		storage,
+		storage,
+		cfg.JWT.AccessTTL,
+		cfg.JWT.RefreshTTL,
+	)
+	apiKeyService := apikeyservice.New(storage, policy)
 
-		r.Post("/", savehandler.New(log, service))
-		r.Patch("/{id}", updatehandler.New(log, service))
-		r.Delete("/{id}", deletehandler.New(log, storage))
+	checker := health.New(cfg.Health.Timeout)
+	checker.Register("database", storage.Ping)
+	checker.Register("migrations", func(ctx context.Context) error {
+		return migrator.CheckVersion(ctx, storage.DB(), cfg.DB.MigrationsTable)
 	})
+	if cfg.Health.CheckExternal {
+		checker.Register("external_api", client.Ping)
+	}
 
-	router.Get("/songs", filterhandler.New(log, service, cfg.PageSizeLimit))
-	router.Get("/songs/{id}", texthandler.New(log, service))
+	specRouter, err := openAPIRouter()
+	if err != nil {
+		panic(err)
+	}
+
+	router, adminRouter, stopRouters := setupRouters(log, cfg, routeDeps{
+		storage:  storage,
+		songs:    service,
+		auth:     authService,
+		apiKeys:  apiKeyService,
+		policy:   policy,
+		checker:  checker,
+		metrics:  appMetrics,
+		logLevel: logLevel,
+		spec:     specRouter,
+	})
 
 	address := fmt.Sprintf("%s:%d", cfg.HTTPServer.Host, cfg.HTTPServer.Port)
 	log.Info("starting server", slog.String("address", address))
@@ -90,11 +170,36 @@ func main() {
 		}
 	}()
 
+	var adminSrv *http.Server
+	if cfg.Admin.Port != 0 {
+		adminAddress := fmt.Sprintf("%s:%d", cfg.Admin.Host, cfg.Admin.Port)
+		log.Info("starting admin server", slog.String("address", adminAddress))
+
+		adminSrv = &http.Server{
+			Addr:         adminAddress,
+			Handler:      adminRouter,
+			ReadTimeout:  cfg.HTTPServer.Timeout,
+			WriteTimeout: cfg.HTTPServer.Timeout,
+			IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+		}
+
+		go func() {
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Error("failed to start admin server", sl.Err(err))
+			}
+		}()
+	}
+
 	log.Info("server started")
 
 	<-done
 	log.Info("stopping server")
 
+	// Fail readiness first so load balancers stop sending new requests
+	// while the server still serves them.
+	checker.Drain()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -104,6 +209,25 @@ func main() {
 		return
 	}
 
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.Error("failed to stop admin server", sl.Err(err))
+		}
+	}
+
+	stopRouters()
+
+	// Views counted since the last flush are saved before the storage
+	// closes.
+	stopViews()
+	if err := viewCounter.Flush(ctx, storage.AddViews); err != nil {
+		log.Error("failed to save song views", sl.Err(err))
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
+
 	if err := storage.Close(); err != nil {
 		log.Error("failed to close storage", sl.Err(err))
 
@@ -113,19 +237,154 @@ func main() {
 	log.Info("server stopped")
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+// setupUsers merges the primary user with the additional USERS entries.
+// Additional users without an explicit role are viewers.
+func setupUsers(cfg *config.Config) map[string]models.User {
+	users := map[string]models.User{
+		cfg.HTTPServer.User: {
+			Password: cfg.HTTPServer.Password,
+			Roles:    []string{cfg.HTTPServer.Role},
+		},
+	}
+
+	for username, password := range cfg.RBAC.Users {
+		role, ok := cfg.RBAC.UserRoles[username]
+		if !ok {
+			role = rbac.RoleViewer
+		}
+
+		users[username] = models.User{
+			Password: password,
+			Roles:    []string{role},
+		}
+	}
+
+	return users
+}
+
+// setupRateLimits returns the read, write and export rate limit middlewares
+// and a func that stops their background work. When rate limiting is
+// disabled they pass every request through.
+func setupRateLimits(log *slog.Logger, cfg *config.Config, storage *postgres.Storage) (read, write, export func(http.Handler) http.Handler, stop func()) {
+	rl := cfg.RateLimit
+
+	if !rl.Enabled {
+		pass := func(next http.Handler) http.Handler { return next }
+
+		return pass, pass, pass, func() {}
+	}
+
+	readLimiter := ratelimit.New(rl.ReadRPS, rl.ReadBurst, rl.IdleTTL)
+	writeLimiter := ratelimit.New(rl.WriteRPS, rl.WriteBurst, rl.IdleTTL)
+	exportLimiter := ratelimit.New(rl.ExportRPS, rl.ExportBurst, rl.IdleTTL)
+
+	read = ratelimitmiddleware.New(log, ratelimitmiddleware.ClassRead, readLimiter, storage, rl.ReadQuota)
+	write = ratelimitmiddleware.New(log, ratelimitmiddleware.ClassWrite, writeLimiter, storage, rl.WriteQuota)
+	export = ratelimitmiddleware.New(log, ratelimitmiddleware.ClassExport, exportLimiter, storage, rl.ExportQuota)
+
+	stop = func() {
+		readLimiter.Stop()
+		writeLimiter.Stop()
+		exportLimiter.Stop()
+	}
+
+	return read, write, export, stop
+}
+
+// purgeExpired periodically drops expired tokens, stored idempotent responses
+// and quota counters of past days.
+func purgeExpired(log *slog.Logger, storage *postgres.Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+
+	for range ticker.C {
+		if err := storage.PurgeExpiredTokens(ctx); err != nil {
+			log.Error("failed to purge expired tokens", sl.Err(err))
+		}
+
+		if err := storage.PurgeIdempotencyKeys(ctx); err != nil {
+			log.Error("failed to purge idempotency keys", sl.Err(err))
+		}
+
+		if err := storage.PurgeQuotas(ctx, time.Now().UTC().AddDate(0, 0, -1)); err != nil {
+			log.Error("failed to purge quotas", sl.Err(err))
+		}
+	}
+}
+
+// similarBatchSize is how many songs the index of similar songs loads at once.
+const similarBatchSize = 1000
+
+// rebuildSimilar loads the index of similar songs, then reloads it every
+// interval unless interval is 0.
+func rebuildSimilar(log *slog.Logger, index *similar.Index, storage *postgres.Storage, interval time.Duration) {
+	ctx := context.Background()
+
+	load := func(ctx context.Context, afterID int) ([]models.SongData, error) {
+		return storage.SongsBatch(ctx, afterID, similarBatchSize, false)
+	}
+
+	rebuild := func() {
+		start := time.Now()
+
+		if err := index.Rebuild(ctx, load); err != nil {
+			log.Error("failed to load similar songs index", sl.Err(err))
+
+			return
+		}
+
+		log.Info("similar songs index loaded", slog.Duration("took", time.Since(start)))
+	}
+
+	rebuild()
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rebuild()
+	}
+}
+
+// setupLogger builds the service logger. The returned level can be changed
+// while the service runs.
+func setupLogger(env string, cfg config.Log) (*slog.Logger, *slog.LevelVar) {
+	level := new(slog.LevelVar)
 
 	switch env {
-	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDev:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envProd:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	case envLocal, envDev:
+		level.Set(slog.LevelDebug)
+	default:
+		level.Set(slog.LevelInfo)
+	}
+
+	if cfg.Level != "" {
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(cfg.Level)); err != nil {
+			panic(err)
+		}
+
+		level.Set(l)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+
+	switch cfg.Format {
+	case logFormatJSON:
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	case logFormatText:
+		handler = slog.NewTextHandler(os.Stdout, opts)
 	default:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		panic(fmt.Sprintf("unknown log format %q", cfg.Format))
 	}
 
-	return log
+	return slog.New(redact.NewHandler(handler, cfg.RedactKeys, cfg.MaxValueLength)), level
 }
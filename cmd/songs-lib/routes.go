@@ -0,0 +1,229 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"effective_mobile/internal/config"
+	createkeyhandler "effective_mobile/internal/http-server/handlers/apikey/create"
+	listkeyshandler "effective_mobile/internal/http-server/handlers/apikey/list"
+	revokekeyhandler "effective_mobile/internal/http-server/handlers/apikey/revoke"
+	loginhandler "effective_mobile/internal/http-server/handlers/auth/login"
+	logouthandler "effective_mobile/internal/http-server/handlers/auth/logout"
+	refreshhandler "effective_mobile/internal/http-server/handlers/auth/refresh"
+	spechandler "effective_mobile/internal/http-server/handlers/docs/spec"
+	uihandler "effective_mobile/internal/http-server/handlers/docs/ui"
+	livehandler "effective_mobile/internal/http-server/handlers/health/live"
+	readyhandler "effective_mobile/internal/http-server/handlers/health/ready"
+	getlevelhandler "effective_mobile/internal/http-server/handlers/loglevel/get"
+	setlevelhandler "effective_mobile/internal/http-server/handlers/loglevel/set"
+	activelinehandler "effective_mobile/internal/http-server/handlers/song/activeline"
+	catalogstatshandler "effective_mobile/internal/http-server/handlers/song/catalogstats"
+	chordshandler "effective_mobile/internal/http-server/handlers/song/chords"
+	deletehandler "effective_mobile/internal/http-server/handlers/song/delete"
+	deletechordshandler "effective_mobile/internal/http-server/handlers/song/deletechords"
+	deletetranslationhandler "effective_mobile/internal/http-server/handlers/song/deletetranslation"
+	duplicateshandler "effective_mobile/internal/http-server/handlers/song/duplicates"
+	exporthandler "effective_mobile/internal/http-server/handlers/song/export"
+	filterhandler "effective_mobile/internal/http-server/handlers/song/filter"
+	lyricshandler "effective_mobile/internal/http-server/handlers/song/lyrics"
+	mergehandler "effective_mobile/internal/http-server/handlers/song/merge"
+	revisionshandler "effective_mobile/internal/http-server/handlers/song/revisions"
+	savehandler "effective_mobile/internal/http-server/handlers/song/save"
+	savechordshandler "effective_mobile/internal/http-server/handlers/song/savechords"
+	savetranslationhandler "effective_mobile/internal/http-server/handlers/song/savetranslation"
+	sectionshandler "effective_mobile/internal/http-server/handlers/song/sections"
+	similarhandler "effective_mobile/internal/http-server/handlers/song/similar"
+	statshandler "effective_mobile/internal/http-server/handlers/song/stats"
+	suggesthandler "effective_mobile/internal/http-server/handlers/song/suggest"
+	synclyricshandler "effective_mobile/internal/http-server/handlers/song/synclyrics"
+	texthandler "effective_mobile/internal/http-server/handlers/song/text"
+	translationhandler "effective_mobile/internal/http-server/handlers/song/translation"
+	updatehandler "effective_mobile/internal/http-server/handlers/song/update"
+	"effective_mobile/internal/http-server/middleware/auth"
+	"effective_mobile/internal/http-server/middleware/authz"
+	"effective_mobile/internal/http-server/middleware/idempotency"
+	"effective_mobile/internal/http-server/middleware/logger"
+	metricsmiddleware "effective_mobile/internal/http-server/middleware/metrics"
+	openapimiddleware "effective_mobile/internal/http-server/middleware/openapi"
+	tracingmiddleware "effective_mobile/internal/http-server/middleware/tracing"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/health"
+	"effective_mobile/internal/lib/metrics"
+	"effective_mobile/internal/lib/rbac"
+	apikeyservice "effective_mobile/internal/service/apikey-service"
+	authservice "effective_mobile/internal/service/auth-service"
+	songservice "effective_mobile/internal/service/song-service"
+	"effective_mobile/internal/storage/postgres"
+	"effective_mobile/swagger"
+)
+
+const specPath = "/openapi.yaml"
+
+// routeDeps is everything the routes are served by.
+type routeDeps struct {
+	storage  *postgres.Storage
+	songs    *songservice.SongService
+	auth     *authservice.AuthService
+	apiKeys  *apikeyservice.APIKeyService
+	policy   *rbac.Policy
+	checker  *health.Checker
+	metrics  *metrics.Metrics
+	logLevel *slog.LevelVar
+	spec     routers.Router
+}
+
+// openAPIRouter matches requests to the operations of the embedded spec.
+func openAPIRouter() (routers.Router, error) {
+	doc, err := swagger.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return gorillamux.NewRouter(doc)
+}
+
+// setupRouters builds the public router and the router of operational
+// endpoints. Without ADMIN_PORT they are the same router. stop ends the
+// background work of their middlewares.
+func setupRouters(log *slog.Logger, cfg *config.Config, deps routeDeps) (router, adminRouter chi.Router, stop func()) {
+	router = chi.NewRouter()
+
+	router.Use(middleware.RequestID)
+	router.Use(tracingmiddleware.New(log))
+	router.Use(logger.New(log, cfg.Log.SuccessSampleRatio))
+	router.Use(metricsmiddleware.New(log, deps.metrics))
+	router.Use(middleware.Recoverer)
+	router.Use(openapimiddleware.New(log, deps.spec, cfg.OpenAPI.ValidateRequests, cfg.OpenAPI.ValidateResponses))
+
+	router.NotFound(problem.NotFound)
+	router.MethodNotAllowed(problem.MethodNotAllowed)
+
+	router.Get("/healthz", livehandler.New())
+	router.Get("/readyz", readyhandler.New(log, deps.checker))
+
+	router.Get(specPath, spechandler.New(swagger.Spec))
+	router.Get("/docs", uihandler.New(specPath))
+
+	router.Route("/auth", func(r chi.Router) {
+		r.Post("/login", loginhandler.New(log, deps.auth))
+		r.Post("/refresh", refreshhandler.New(log, deps.auth))
+		r.With(auth.New(log, authRealm, deps.auth)).Post("/logout", logouthandler.New(log, deps.auth))
+	})
+
+	var anonymousRoles []string
+	if cfg.RBAC.AnonymousRole != "" {
+		anonymousRoles = []string{cfg.RBAC.AnonymousRole}
+	}
+
+	readLimit, writeLimit, exportLimit, stop := setupRateLimits(log, cfg, deps.storage)
+
+	router.Route("/songs", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(auth.NewOptional(log, authRealm, deps.auth, anonymousRoles))
+			r.Use(readLimit)
+			r.Use(authz.Require(log, deps.policy, rbac.PermSongsRead))
+
+			r.Get("/", filterhandler.New(log, deps.songs, cfg.PageSizeLimit))
+			r.Get("/{id}", texthandler.New(log, deps.songs))
+			r.Get("/{id}/sections", sectionshandler.New(log, deps.songs))
+			r.Get("/{id}/lyrics", lyricshandler.New(log, deps.songs))
+			r.Get("/{id}/lyrics/line", activelinehandler.New(log, deps.songs))
+			r.Get("/{id}/lyrics/{lang}", translationhandler.New(log, deps.songs))
+			r.Get("/{id}/chords", chordshandler.New(log, deps.songs))
+			r.Get("/{id}/stats", statshandler.New(log, deps.songs))
+			r.Get("/{id}/similar", similarhandler.New(log, deps.songs))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.New(log, authRealm, deps.auth))
+			r.Use(exportLimit)
+			r.Use(authz.Require(log, deps.policy, rbac.PermSongsExport))
+
+			r.Get("/export", exporthandler.New(log, deps.songs))
+		})
+
+		// Finding duplicates compares the whole catalog, and its results are
+		// for the editors who merge them.
+		r.Group(func(r chi.Router) {
+			r.Use(auth.New(log, authRealm, deps.auth))
+			r.Use(exportLimit)
+			r.Use(authz.Require(log, deps.policy, rbac.PermSongsUpdate))
+
+			r.Get("/duplicates", duplicateshandler.New(log, deps.songs))
+			r.Get("/{id}/revisions", revisionshandler.New(log, deps.songs))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.New(log, authRealm, deps.auth))
+			r.Use(writeLimit)
+
+			// Each route is authorized before its response can be stored,
+			// so a retry after a role change is not answered with a 403.
+			idem := idempotency.New(log, deps.storage, cfg.IdempotencyTTL)
+
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsCreate), idem).Post("/", savehandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Patch("/{id}", updatehandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Put("/{id}/lyrics", synclyricshandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Put("/{id}/lyrics/{lang}", savetranslationhandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Delete("/{id}/lyrics/{lang}", deletetranslationhandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Put("/{id}/chords", savechordshandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsUpdate), idem).Delete("/{id}/chords", deletechordshandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsDelete), idem).Post("/{id}/merge", mergehandler.New(log, deps.songs))
+			r.With(authz.Require(log, deps.policy, rbac.PermSongsDelete), idem).Delete("/{id}", deletehandler.New(log, deps.songs))
+		})
+	})
+
+	// Catalog statistics scan the lyrics of every matching song, so they
+	// share the rate limit of exports while being open to readers.
+	router.Group(func(r chi.Router) {
+		r.Use(auth.NewOptional(log, authRealm, deps.auth, anonymousRoles))
+		r.Use(exportLimit)
+		r.Use(authz.Require(log, deps.policy, rbac.PermSongsRead))
+
+		r.Get("/stats", catalogstatshandler.New(log, deps.songs))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(auth.NewOptional(log, authRealm, deps.auth, anonymousRoles))
+		r.Use(readLimit)
+		r.Use(authz.Require(log, deps.policy, rbac.PermSongsRead))
+
+		r.Get("/suggest", suggesthandler.New(log, deps.songs, cfg.Suggest.Timeout))
+	})
+
+	router.Route("/admin/api-keys", func(r chi.Router) {
+		r.Use(auth.New(log, authRealm, deps.auth))
+		r.Use(authz.Require(log, deps.policy, rbac.PermAPIKeysManage))
+
+		// Creating a key is not idempotent: its response holds the secret,
+		// which must not be stored.
+		r.Post("/", createkeyhandler.New(log, deps.apiKeys))
+		r.Get("/", listkeyshandler.New(log, deps.apiKeys))
+		r.With(idempotency.New(log, deps.storage, cfg.IdempotencyTTL)).Delete("/{id}", revokekeyhandler.New(log, deps.apiKeys))
+	})
+
+	adminRouter = router
+	if cfg.Admin.Port != 0 {
+		adminRouter = chi.NewRouter()
+		adminRouter.Use(middleware.Recoverer)
+		adminRouter.NotFound(problem.NotFound)
+		adminRouter.MethodNotAllowed(problem.MethodNotAllowed)
+	}
+
+	adminRouter.Method(http.MethodGet, "/metrics", deps.metrics.Handler())
+	adminRouter.Route("/admin/log-level", func(r chi.Router) {
+		r.Use(auth.New(log, authRealm, deps.auth))
+		r.Use(authz.Require(log, deps.policy, rbac.PermLogsManage))
+
+		r.Get("/", getlevelhandler.New(deps.logLevel))
+		r.Put("/", setlevelhandler.New(log, deps.logLevel))
+	})
+
+	return router, adminRouter, stop
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"effective_mobile/internal/config"
+	"effective_mobile/internal/lib/metrics"
+	"effective_mobile/swagger"
+)
+
+// TestRoutesMatchSpec fails when a route is served without being described
+// in swagger/swagger.yaml, or the spec describes a route that is not served.
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := swagger.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+
+	specRouter, err := openAPIRouter()
+	if err != nil {
+		t.Fatalf("build spec router: %v", err)
+	}
+
+	router, _, _ := setupRouters(slog.New(slog.NewTextHandler(io.Discard, nil)), &config.Config{}, routeDeps{
+		metrics: metrics.New(nil),
+		spec:    specRouter,
+	})
+
+	served := make(map[string]bool)
+	err = chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		served[method+" "+normalizeRoute(route)] = true
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for _, route := range sortedKeys(served) {
+		if !documented[route] {
+			t.Errorf("route %s is served but missing from the spec", route)
+		}
+	}
+
+	for _, route := range sortedKeys(documented) {
+		if !served[route] {
+			t.Errorf("route %s is in the spec but not served", route)
+		}
+	}
+}
+
+// normalizeRoute drops the trailing slash chi keeps on the index route of
+// a subrouter, so /songs/ compares equal to the spec path /songs.
+func normalizeRoute(route string) string {
+	if route != "/" {
+		route = strings.TrimSuffix(route, "/")
+	}
+
+	return route
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"net/http"
+
+	"effective_mobile/pkg/songsclient"
+)
+
+func newClient(s settings) (*songsclient.Client, error) {
+	opts := []songsclient.Option{
+		songsclient.WithHTTPClient(&http.Client{Timeout: s.Timeout}),
+	}
+
+	switch {
+	case s.APIKey != "":
+		opts = append(opts, songsclient.WithAuth(songsclient.APIKey(s.APIKey)))
+	case s.Token != "":
+		opts = append(opts, songsclient.WithAuth(songsclient.BearerToken(s.Token)))
+	case s.User != "":
+		opts = append(opts, songsclient.WithAuth(songsclient.BasicAuth(s.User, s.Password)))
+	}
+
+	return songsclient.New(s.Server, opts...)
+}
@@ -0,0 +1,696 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"effective_mobile/internal/lib/chords"
+	"effective_mobile/internal/lib/lyrics"
+	"effective_mobile/pkg/songsclient"
+)
+
+func addCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("add", flag.ContinueOnError)
+	group := fset.String("group", "", "group name")
+	song := fset.String("song", "", "song name")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *group == "" || *song == "" {
+		return fmt.Errorf("%w: add needs -group and -song", errUsage)
+	}
+
+	id, err := c.AddSong(ctx, *group, *song, callOptions(*key)...)
+	if err != nil {
+		return err
+	}
+
+	return p.value(map[string]int{"id": id})
+}
+
+func getCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("get", flag.ContinueOnError)
+	verse := fset.Int("verse", 1, "verse number")
+	lang := fset.String("lang", "", "read the verse from the lyrics in this language")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	if *lang == "" {
+		text, err := c.Verse(ctx, id, *verse)
+		if err != nil {
+			return err
+		}
+
+		return p.text("text", text)
+	}
+
+	text, err := c.VerseIn(ctx, id, *verse, *lang)
+	if err != nil {
+		return err
+	}
+
+	if text.Warning != "" {
+		fmt.Fprintln(os.Stderr, "warning:", text.Warning)
+	}
+
+	return p.text("text", text.Text)
+}
+
+type verse struct {
+	Verse int    `json:"verse"`
+	Text  string `json:"text"`
+}
+
+func versesCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("verses", flag.ContinueOnError)
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	verses := make([]verse, 0)
+
+	for n := 1; ; n++ {
+		text, err := c.Verse(ctx, id, n)
+		if n > 1 && errors.Is(err, songsclient.ErrBadRequest) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		verses = append(verses, verse{Verse: n, Text: text})
+	}
+
+	if p.format != outputTable {
+		return p.value(verses)
+	}
+
+	for i, v := range verses {
+		if i > 0 {
+			fmt.Fprintln(p.w)
+		}
+		fmt.Fprintln(p.w, v.Text)
+	}
+
+	return nil
+}
+
+func sectionsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("sections", flag.ContinueOnError)
+	sectionType := fset.String("section", "", "only sections of this type")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	sections, err := c.Sections(ctx, id, *sectionType)
+	if err != nil {
+		return err
+	}
+
+	if p.format != outputTable {
+		return p.value(sections)
+	}
+
+	for i, s := range sections {
+		if i > 0 {
+			fmt.Fprintln(p.w)
+		}
+		fmt.Fprintf(p.w, "[%s %d]\n%s\n", s.Type, s.Number, s.Text)
+	}
+
+	return nil
+}
+
+func lyricsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("lyrics", flag.ContinueOnError)
+	lrc := fset.Bool("lrc", false, "print synced lyrics in LRC format")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	songLyrics, err := c.Lyrics(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if *lrc && !songLyrics.Synced {
+		return fmt.Errorf("song %d: %w", id, songsclient.ErrNotSynced)
+	}
+
+	if p.format != outputTable {
+		return p.value(songLyrics)
+	}
+
+	if *lrc {
+		_, err := fmt.Fprint(p.w, lyrics.FormatLRC(songLyrics.Lines))
+
+		return err
+	}
+
+	_, err = fmt.Fprintln(p.w, songLyrics.Text)
+
+	return err
+}
+
+// setLRCCommand uploads synced lyrics from an LRC file or stdin.
+func setLRCCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("set-lrc", flag.ContinueOnError)
+	file := fset.String("f", "-", "LRC file, - for stdin")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readFile(*file)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SetLRC(ctx, id, string(data), callOptions(*key)...); err != nil {
+		return err
+	}
+
+	return p.text("status", "synced")
+}
+
+// translateCommand uploads the lyrics of a song in one language from a
+// file or stdin.
+func translateCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("translate", flag.ContinueOnError)
+	lang := fset.String("lang", "", "BCP-47 language of the lyrics")
+	file := fset.String("f", "-", "lyrics file, - for stdin")
+	original := fset.Bool("original", false, "the lyrics are the original, not a translation")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	if *lang == "" {
+		return fmt.Errorf("%w: translate needs -lang", errUsage)
+	}
+
+	data, err := readFile(*file)
+	if err != nil {
+		return err
+	}
+
+	warning, err := c.SaveTranslation(ctx, id, *lang, string(data), *original, callOptions(*key)...)
+	if err != nil {
+		return err
+	}
+
+	if warning != "" {
+		fmt.Fprintln(os.Stderr, "warning:", warning)
+	}
+
+	return p.text("status", "saved")
+}
+
+// chordsCommand prints the chord sheet of a song with chords above the
+// lyrics.
+func chordsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("chords", flag.ContinueOnError)
+	transpose := fset.Int("transpose", 0, "semitones to transpose the chords by")
+	capo := fset.Int("capo", -1, "fret of the capo to show the chords for (default: capo of the sheet)")
+	verse := fset.Int("verse", 0, "print only this verse")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	opts := songsclient.ChordOptions{Transpose: *transpose, Verse: *verse}
+	if *capo >= 0 {
+		opts.Capo = capo
+	}
+
+	sheet, err := c.Chords(ctx, id, opts)
+	if err != nil {
+		return err
+	}
+
+	if p.format != outputTable {
+		return p.value(sheet)
+	}
+
+	_, err = fmt.Fprint(p.w, chords.Text(sheet))
+
+	return err
+}
+
+// setChordsCommand uploads a chord sheet from a ChordPro file or stdin.
+func setChordsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("set-chords", flag.ContinueOnError)
+	file := fset.String("f", "-", "ChordPro file, - for stdin")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readFile(*file)
+	if err != nil {
+		return err
+	}
+
+	if err := c.SaveChords(ctx, id, string(data), callOptions(*key)...); err != nil {
+		return err
+	}
+
+	return p.text("status", "saved")
+}
+
+// statsCommand prints statistics of the lyrics of a song.
+func statsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("stats", flag.ContinueOnError)
+	lang := fset.String("lang", "", "count the lyrics in this language")
+	top := fset.Int("top", 0, "number of top words (default: server default)")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.Stats(ctx, id, *lang, *top)
+	if err != nil {
+		return err
+	}
+
+	return p.lyricsStats(stats)
+}
+
+// catalogCommand prints statistics of the lyrics of the songs matching the
+// filters, per group and per release year.
+func catalogCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	filter := filterFlags(fset)
+	top := fset.Int("top", 0, "number of top words per group (default: server default)")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	stats, err := c.CatalogStats(ctx, filter(), *top)
+	if err != nil {
+		return err
+	}
+
+	return p.catalogStats(stats)
+}
+
+// similarCommand prints the songs similar to a song with the reasons.
+func similarCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("similar", flag.ContinueOnError)
+	limit := fset.Int("limit", 0, "maximum number of songs (default: server default)")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	similar, err := c.Similar(ctx, id, *limit)
+	if err != nil {
+		return err
+	}
+
+	return p.similar(similar)
+}
+
+// suggestCommand prints the group names and song titles suggested for the
+// text typed so far.
+func suggestCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("suggest", flag.ContinueOnError)
+	kind := fset.String("type", "", "group, song or all (default: all)")
+	limit := fset.Int("limit", 0, "maximum number of suggestions (default: server default)")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if fset.NArg() == 0 {
+		return fmt.Errorf("%w: suggest needs the text typed so far", errUsage)
+	}
+
+	suggestions, err := c.Suggest(ctx, strings.Join(fset.Args(), " "), *kind, *limit)
+	if err != nil {
+		return err
+	}
+
+	return p.suggestions(suggestions)
+}
+
+// duplicatesCommand prints pairs of songs that are likely duplicates.
+func duplicatesCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("duplicates", flag.ContinueOnError)
+	threshold := fset.Float64("threshold", 0, "lyrics similarity from 0 to 1 (default: server default)")
+	limit := fset.Int("limit", 0, "maximum number of pairs (default: server default)")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	pairs, err := c.Duplicates(ctx, *threshold, *limit)
+	if err != nil {
+		return err
+	}
+
+	return p.duplicates(pairs)
+}
+
+// mergeCommand merges a song into another and deletes it.
+func mergeCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("merge", flag.ContinueOnError)
+	into := fset.Int("into", 0, "ID of the song to merge into")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	if *into == 0 {
+		return fmt.Errorf("%w: merge needs -into", errUsage)
+	}
+
+	song, err := c.MergeSong(ctx, id, *into, callOptions(*key)...)
+	if err != nil {
+		return err
+	}
+
+	return p.songs([]songsclient.Song{song})
+}
+
+// revisionsCommand prints the revisions of a song.
+func revisionsCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("revisions", flag.ContinueOnError)
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := c.Revisions(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return p.revisions(revisions)
+}
+
+func listCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("list", flag.ContinueOnError)
+	filter := filterFlags(fset)
+	page := fset.Int("page", 1, "page number")
+	perPage := fset.Int("per-page", 0, "songs per page (default: server limit)")
+	all := fset.Bool("all", false, "fetch every page, ignoring -page")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if !*all {
+		songs, err := c.ListSongs(ctx, filter(), *page, *perPage)
+		if err != nil {
+			return err
+		}
+
+		return p.songs(songs)
+	}
+
+	songs := make([]songsclient.Song, 0)
+
+	it := c.Songs(filter(), *perPage)
+	for it.Next(ctx) {
+		songs = append(songs, it.Song())
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return p.songs(songs)
+}
+
+func updateCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("update", flag.ContinueOnError)
+	group := fset.String("group", "", "new group name")
+	song := fset.String("song", "", "new song name")
+	releaseDate := fset.String("release-date", "", "new release date, DD.MM.YYYY")
+	text := fset.String("text", "", "new lyrics")
+	link := fset.String("link", "", "new link")
+	key := fset.String("idempotency-key", "", "Idempotency-Key to make retries safe")
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	var req songsclient.SongUpdate
+	fset.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "group":
+			req.Group = group
+		case "song":
+			req.Song = song
+		case "release-date":
+			req.ReleaseDate = releaseDate
+		case "text":
+			req.Text = text
+		case "link":
+			req.Link = link
+		}
+	})
+
+	if req == (songsclient.SongUpdate{}) {
+		return fmt.Errorf("%w: update needs at least one field flag", errUsage)
+	}
+
+	if err := c.UpdateSong(ctx, id, req, callOptions(*key)...); err != nil {
+		return err
+	}
+
+	return p.text("status", "updated")
+}
+
+func deleteCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("delete", flag.ContinueOnError)
+
+	id, err := parseID(fset, args)
+	if err != nil {
+		return err
+	}
+
+	if err := c.DeleteSong(ctx, id); err != nil {
+		return err
+	}
+
+	return p.text("status", "deleted")
+}
+
+type importSong struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+}
+
+type importResult struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// importCommand adds songs from a JSON array of {"group", "song"} objects or
+// from the output of export. Each song is sent with an Idempotency-Key
+// derived from its name, so an interrupted import can simply be rerun.
+func importCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("import", flag.ContinueOnError)
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fset.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	requests, err := readImport(r)
+	if err != nil {
+		return err
+	}
+
+	results := make([]importResult, 0, len(requests))
+	var lastErr error
+
+	for _, req := range requests {
+		res := importResult{Group: req.Group, Song: req.Song}
+
+		id, err := c.AddSong(ctx, req.Group, req.Song, songsclient.WithIdempotencyKey(importKey(req)))
+		if err != nil {
+			res.Error = err.Error()
+			lastErr = err
+		}
+		res.ID = id
+
+		results = append(results, res)
+	}
+
+	if p.format == outputTable {
+		for _, res := range results {
+			if res.Error != "" {
+				fmt.Fprintf(p.w, "FAILED  %s - %s: %s\n", res.Group, res.Song, res.Error)
+			} else {
+				fmt.Fprintf(p.w, "ADDED   %s - %s (id %d)\n", res.Group, res.Song, res.ID)
+			}
+		}
+	} else if err := p.value(results); err != nil {
+		return err
+	}
+
+	return lastErr
+}
+
+func exportCommand(ctx context.Context, c *songsclient.Client, p printer, args []string) error {
+	fset := flag.NewFlagSet("export", flag.ContinueOnError)
+	filter := filterFlags(fset)
+	out := fset.String("o", "", "write to file instead of stdout")
+
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	songs, err := c.ExportSongs(ctx, filter())
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		p.w = f
+	}
+
+	return p.songs(songs)
+}
+
+func readImport(r io.Reader) ([]importSong, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []importSong
+	if err := json.Unmarshal(data, &requests); err == nil {
+		return requests, nil
+	}
+
+	var exported struct {
+		Songs []importSong `json:"songs"`
+	}
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("import expects a JSON array of songs or export output: %w", err)
+	}
+
+	return exported.Songs, nil
+}
+
+func importKey(req importSong) string {
+	sum := sha256.Sum256([]byte(req.Group + "\x00" + req.Song))
+
+	return "songsctl-import-" + hex.EncodeToString(sum[:16])
+}
+
+// readFile reads name, or stdin when name is "-".
+func readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(name)
+}
+
+func callOptions(idempotencyKey string) []songsclient.CallOption {
+	if idempotencyKey == "" {
+		return nil
+	}
+
+	return []songsclient.CallOption{songsclient.WithIdempotencyKey(idempotencyKey)}
+}
+
+// filterFlags registers the song filter flags on fset and returns a function
+// building the filter from them.
+func filterFlags(fset *flag.FlagSet) func() songsclient.Filter {
+	group := fset.String("group", "", "filter by group name")
+	song := fset.String("song", "", "filter by song name")
+	releaseDate := fset.String("release-date", "", "filter by release date, DD.MM.YYYY")
+	language := fset.String("language", "", "filter by detected lyrics language, e.g. ru")
+	query := fset.String("q", "", "search names and lyrics, in Cyrillic or Latin")
+
+	return func() songsclient.Filter {
+		return songsclient.Filter{Group: *group, Song: *song, ReleaseDate: *releaseDate, Language: *language, Query: *query}
+	}
+}
+
+// parseID parses the flags of fset, accepting them before or after the
+// leading song ID argument.
+func parseID(fset *flag.FlagSet, args []string) (int, error) {
+	if err := fset.Parse(args); err != nil {
+		return 0, err
+	}
+
+	rest := fset.Args()
+	if len(rest) == 0 {
+		return 0, fmt.Errorf("%w: %s needs a song ID", errUsage, fset.Name())
+	}
+
+	id, err := strconv.Atoi(rest[0])
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid song ID %q", errUsage, rest[0])
+	}
+
+	if err := fset.Parse(rest[1:]); err != nil {
+		return 0, err
+	}
+
+	if fset.NArg() > 0 {
+		return 0, fmt.Errorf("%w: unexpected arguments %v", errUsage, fset.Args())
+	}
+
+	return id, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// settings are resolved from flags, then SONGSCTL_* environment variables,
+// then the config file.
+type settings struct {
+	Server   string        `yaml:"server"`
+	User     string        `yaml:"user"`
+	Password string        `yaml:"password"`
+	Token    string        `yaml:"token"`
+	APIKey   string        `yaml:"apiKey"`
+	Output   string        `yaml:"output"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "songsctl", "config.yaml")
+}
+
+func loadSettings(fset *flag.FlagSet, flags settings, configPath string) (settings, error) {
+	var file settings
+
+	if configPath != "" {
+		// A missing file is only an error when it was asked for explicitly.
+		explicit := isSet(fset, "config") || os.Getenv("SONGSCTL_CONFIG") != ""
+
+		data, err := os.ReadFile(configPath)
+		if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+			return settings{}, fmt.Errorf("read config: %w", err)
+		}
+
+		if err == nil {
+			if err := yaml.Unmarshal(data, &file); err != nil {
+				return settings{}, fmt.Errorf("parse config %s: %w", configPath, err)
+			}
+		}
+	}
+
+	s := settings{
+		Server:   pick(fset, "server", flags.Server, "SONGSCTL_SERVER", file.Server, "http://localhost:8080"),
+		User:     pick(fset, "user", flags.User, "SONGSCTL_USER", file.User, ""),
+		Password: pick(fset, "password", flags.Password, "SONGSCTL_PASSWORD", file.Password, ""),
+		Token:    pick(fset, "token", flags.Token, "SONGSCTL_TOKEN", file.Token, ""),
+		APIKey:   pick(fset, "api-key", flags.APIKey, "SONGSCTL_API_KEY", file.APIKey, ""),
+		Output:   pick(fset, "output", flags.Output, "SONGSCTL_OUTPUT", file.Output, outputTable),
+		Timeout:  flags.Timeout,
+	}
+
+	if !isSet(fset, "timeout") && file.Timeout > 0 {
+		s.Timeout = file.Timeout
+	}
+
+	switch s.Output {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		return settings{}, fmt.Errorf("%w: unknown output format %q", errUsage, s.Output)
+	}
+
+	return s, nil
+}
+
+func pick(fset *flag.FlagSet, name, flagValue, env, fileValue, defaultValue string) string {
+	if isSet(fset, name) {
+		return flagValue
+	}
+
+	if v, ok := os.LookupEnv(env); ok {
+		return v
+	}
+
+	if fileValue != "" {
+		return fileValue
+	}
+
+	return defaultValue
+}
+
+func isSet(fset *flag.FlagSet, name string) bool {
+	set := false
+	fset.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
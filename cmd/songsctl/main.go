@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"effective_mobile/pkg/songsclient"
+)
+
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitAuth        = 4
+	exitRejected    = 5
+	exitRateLimited = 6
+	exitServer      = 7
+)
+
+const usage = `usage: songsctl [flags] <command> [args]
+
+commands:
+  add -group G -song S           add a song
+  get ID [-verse N] [-lang L]    print a verse of a song (default 1)
+  verses ID                      print all verses of a song
+  sections ID [-section TYPE]    print the sections of a song (verse, chorus, ...)
+  lyrics ID [-lrc]               print the lyrics of a song, as LRC if synced
+  set-lrc ID [-f FILE]           upload synced lyrics in LRC format
+  translate ID -lang L [-f FILE] upload the lyrics in a language
+  chords ID [-transpose N]       print the chord sheet of a song
+  set-chords ID [-f FILE]        upload a chord sheet in ChordPro format
+  stats ID [-lang L] [-top N]    print word counts and top words of a song
+  similar ID [-limit N]          list songs similar to a song and why
+  catalog [filters] [-top N]     print lyrics statistics per group and year
+  list [filters] [-page N]       list songs
+  suggest [-type T] TEXT         suggest group names and song titles for TEXT
+  duplicates [-threshold F]      list pairs of songs that are likely duplicates
+  merge ID -into N               merge a song into another and delete it
+  revisions ID                   list the revisions of a song
+  update ID [fields]             update song fields
+  delete ID                      delete a song
+  import [FILE]                  add songs from a JSON file or stdin
+  export [filters] [-o FILE]     export all songs matching the filters
+
+Run "songsctl <command> -h" for the flags of a command.
+
+Credentials are read from flags, then SONGSCTL_SERVER, SONGSCTL_USER,
+SONGSCTL_PASSWORD, SONGSCTL_TOKEN, SONGSCTL_API_KEY and SONGSCTL_OUTPUT,
+then the config file.
+
+exit codes:
+  0 success, 1 error, 2 usage, 3 not found (404), 4 unauthorized or
+  forbidden (401, 403), 5 rejected request (400, 409, 422),
+  6 rate limited (429), 7 server error (5xx)
+
+flags:
+`
+
+var errUsage = errors.New("usage error")
+
+type command func(ctx context.Context, c *songsclient.Client, p printer, args []string) error
+
+var commands = map[string]command{
+	"add":        addCommand,
+	"get":        getCommand,
+	"verses":     versesCommand,
+	"sections":   sectionsCommand,
+	"lyrics":     lyricsCommand,
+	"set-lrc":    setLRCCommand,
+	"translate":  translateCommand,
+	"chords":     chordsCommand,
+	"set-chords": setChordsCommand,
+	"stats":      statsCommand,
+	"similar":    similarCommand,
+	"catalog":    catalogCommand,
+	"list":       listCommand,
+	"suggest":    suggestCommand,
+	"duplicates": duplicatesCommand,
+	"merge":      mergeCommand,
+	"revisions":  revisionsCommand,
+	"update":     updateCommand,
+	"delete":     deleteCommand,
+	"import":     importCommand,
+	"export":     exportCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	fset := flag.NewFlagSet("songsctl", flag.ContinueOnError)
+	fset.Usage = func() {
+		fmt.Fprint(fset.Output(), usage)
+		fset.PrintDefaults()
+	}
+
+	var flags settings
+	var configPath string
+
+	fset.StringVar(&flags.Server, "server", "", "API base URL (default http://localhost:8080)")
+	fset.StringVar(&flags.User, "user", "", "basic auth user")
+	fset.StringVar(&flags.Password, "password", "", "basic auth password")
+	fset.StringVar(&flags.Token, "token", "", "bearer access token")
+	fset.StringVar(&flags.APIKey, "api-key", "", "API key")
+	fset.StringVar(&flags.Output, "output", "", "output format: table, json or yaml (default table)")
+	fset.DurationVar(&flags.Timeout, "timeout", 30*time.Second, "request timeout")
+	fset.StringVar(&configPath, "config", envOr("SONGSCTL_CONFIG", defaultConfigPath()), "config file")
+
+	if err := fset.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	if fset.NArg() == 0 {
+		fset.Usage()
+
+		return exitUsage
+	}
+
+	cmd, ok := commands[fset.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", fset.Arg(0))
+
+		return exitUsage
+	}
+
+	s, err := loadSettings(fset, flags, configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return exitCode(err)
+	}
+
+	c, err := newClient(s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+
+		return exitUsage
+	}
+
+	err = cmd(context.Background(), c, printer{w: os.Stdout, format: s.Output}, fset.Args()[1:])
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	return exitCode(err)
+}
+
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	if errors.Is(err, errUsage) {
+		return exitUsage
+	}
+
+	var apiErr *songsclient.Error
+	if !errors.As(err, &apiErr) {
+		return exitError
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusNotFound:
+		return exitNotFound
+	case apiErr.StatusCode == http.StatusUnauthorized, apiErr.StatusCode == http.StatusForbidden:
+		return exitAuth
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		return exitRateLimited
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		return exitServer
+	case apiErr.StatusCode >= http.StatusBadRequest:
+		return exitRejected
+	default:
+		return exitError
+	}
+}
+
+func envOr(key, defaultValue string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+
+	return defaultValue
+}
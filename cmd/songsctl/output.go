@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"effective_mobile/internal/domain/models"
+)
+
+// printer renders command results in the selected output format. Table
+// output falls back to plain text for values without a tabular form.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) songs(songs []models.SongData) error {
+	if p.format != outputTable {
+		return p.value(songs)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tGROUP\tSONG\tRELEASE DATE\tLANGUAGE\tLINK")
+
+	for _, s := range songs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", s.ID, s.Group, s.Song, s.ReleaseDate, s.Language, s.Link)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) lyricsStats(stats models.LyricsStats) error {
+	if p.format != outputTable {
+		return p.value(stats)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	if stats.Lang != "" {
+		fmt.Fprintf(tw, "LANGUAGE\t%s\n", stats.Lang)
+	}
+	fmt.Fprintf(tw, "LINES\t%d\n", stats.Lines)
+	fmt.Fprintf(tw, "WORDS\t%d (%d unique)\n", stats.Words, stats.UniqueWords)
+	fmt.Fprintf(tw, "VERSES\t%d\n", stats.Verses)
+	fmt.Fprintf(tw, "CHORUSES\t%d (%.0f%% of lines)\n", stats.Choruses, stats.ChorusRatio*100)
+	fmt.Fprintf(tw, "READING\t%s\n", time.Duration(stats.ReadingSeconds)*time.Second)
+	fmt.Fprintf(tw, "SINGING\t%s\n", time.Duration(stats.SingingSeconds)*time.Second)
+	fmt.Fprintf(tw, "TOP WORDS\t%s\n", wordCounts(stats.TopWords))
+
+	return tw.Flush()
+}
+
+func (p printer) catalogStats(stats models.CatalogStats) error {
+	if p.format != outputTable {
+		return p.value(stats)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tSONGS\tWORDS\tTOP WORDS")
+
+	for _, a := range stats.Artists {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", a.Group, a.Songs, a.Words, wordCounts(a.TopWords))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "YEAR\tSONGS\tAVG WORDS\tAVG LINES")
+
+	for _, y := range stats.Years {
+		fmt.Fprintf(tw, "%d\t%d\t%.1f\t%.1f\n", y.Year, y.Songs, y.AverageWords, y.AverageLines)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) similar(similar []models.SimilarSong) error {
+	if p.format != outputTable {
+		return p.value(similar)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tGROUP\tSONG\tSCORE\tWHY")
+
+	for _, s := range similar {
+		reasons := make([]string, len(s.Reasons))
+		for i, r := range s.Reasons {
+			reasons[i] = r.Detail
+			if len(r.Words) > 0 {
+				reasons[i] += " (" + strings.Join(r.Words, ", ") + ")"
+			}
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.2f\t%s\n", s.Song.ID, s.Song.Group, s.Song.Song, s.Score, strings.Join(reasons, "; "))
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) suggestions(suggestions []models.Suggestion) error {
+	if p.format != outputTable {
+		return p.value(suggestions)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tTEXT\tMATCH\tVIEWS\tIDS")
+
+	for _, s := range suggestions {
+		ids := make([]string, len(s.IDs))
+		for i, id := range s.IDs {
+			ids[i] = strconv.Itoa(id)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", s.Type, s.Text, s.Match, s.Popularity, strings.Join(ids, ","))
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) duplicates(pairs []models.DuplicatePair) error {
+	if p.format != outputTable {
+		return p.value(pairs)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tGROUP\tSONG\tID\tGROUP\tSONG\tSAME NAME\tLYRICS")
+
+	for _, d := range pairs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%t\t%.0f%%\n",
+			d.First.ID, d.First.Group, d.First.Song, d.Second.ID, d.Second.Group, d.Second.Song,
+			d.SameKey, d.LyricsSimilarity*100)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) revisions(revisions []models.Revision) error {
+	if p.format != outputTable {
+		return p.value(revisions)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCREATED\tACTION\tRELATED\tACTOR\tGROUP\tSONG")
+
+	for _, r := range revisions {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			r.ID, r.CreatedAt.Format(time.RFC3339), r.Action, r.RelatedSongID, r.Actor, r.Snapshot.Group, r.Snapshot.Song)
+	}
+
+	return tw.Flush()
+}
+
+func wordCounts(words []models.WordCount) string {
+	parts := make([]string, 0, len(words))
+	for _, w := range words {
+		parts = append(parts, fmt.Sprintf("%s (%d)", w.Word, w.Count))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (p printer) value(v interface{}) error {
+	switch p.format {
+	case outputJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	case outputYAML:
+		// Go through JSON so YAML keys match the API field names.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+
+		enc := yaml.NewEncoder(p.w)
+		defer enc.Close()
+
+		return enc.Encode(generic)
+	default:
+		_, err := fmt.Fprintln(p.w, v)
+
+		return err
+	}
+}
+
+// text prints a plain string as is in table mode and as a field otherwise.
+func (p printer) text(key, value string) error {
+	if p.format == outputTable {
+		_, err := fmt.Fprintln(p.w, value)
+
+		return err
+	}
+
+	return p.value(map[string]string{key: value})
+}
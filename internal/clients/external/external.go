@@ -1,6 +1,7 @@
 package external
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -8,28 +9,40 @@ import (
 	"net/url"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
 	"effective_mobile/internal/clients"
 	"effective_mobile/internal/domain/models"
 	"effective_mobile/internal/lib/logger/sl"
 )
 
 type Client struct {
-	baseURL string
-	log     *slog.Logger
-	client  *http.Client
+	baseURL  string
+	log      *slog.Logger
+	client   *http.Client
+	observer CallObserver
+}
+
+// CallObserver is told the status and latency of every call; status is 0
+// when the request failed before a response was received.
+type CallObserver interface {
+	ExternalCall(status int, duration time.Duration)
 }
 
-func New(log *slog.Logger, baseURL string, timeout time.Duration) *Client {
+func New(log *slog.Logger, baseURL string, timeout time.Duration, observer CallObserver) *Client {
 	return &Client{
 		baseURL: baseURL,
 		log:     log,
 		client: &http.Client{
 			Timeout: timeout,
+			// The transport starts a client span and injects traceparent.
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		observer: observer,
 	}
 }
 
-func (c *Client) FetchSongDetails(group, song string) (*models.SongData, error) {
+func (c *Client) FetchSongDetails(ctx context.Context, group, song string) (*models.SongData, error) {
 	const op = "clients.external.FetchSong"
 
 	encodedGroup := url.QueryEscape(group)
@@ -39,14 +52,25 @@ func (c *Client) FetchSongDetails(group, song string) (*models.SongData, error)
 
 	c.log.Info("fetching song details", slog.String("url", url))
 
-	resp, err := c.client.Get(url)
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.observer.ExternalCall(0, time.Since(start))
+
 		c.log.Error("failed to make get reqest", sl.Err(err))
 
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer resp.Body.Close()
 
+	c.observer.ExternalCall(resp.StatusCode, time.Since(start))
+
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusBadRequest {
 			c.log.Error("get request status code: ", sl.Err(clients.ErrBadRequest))
@@ -70,3 +94,26 @@ func (c *Client) FetchSongDetails(group, song string) (*models.SongData, error)
 
 	return &detail, nil
 }
+
+// Ping checks that the external API answers. Any response below 500 counts,
+// since the API has no dedicated health endpoint.
+func (c *Client) Ping(ctx context.Context) error {
+	const op = "clients.external.Ping"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s: %w: status %d", op, clients.ErrInternal, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -15,6 +15,9 @@ type Database struct {
 	Name     string `env:"DB_NAME" env-required:"true"`
 	SSLMode  string `env:"DB_SSLMODE" env-required:"true"`
 	Port     int    `env:"DB_PORT" env-required:"true"`
+
+	AutoMigrate     bool   `env:"AUTO_MIGRATE" env-default:"false"`
+	MigrationsTable string `env:"MIGRATIONS_TABLE" env-default:"migrations"`
 }
 
 type HTTPServer struct {
@@ -22,16 +25,124 @@ type HTTPServer struct {
 	Host        string        `env:"APP_HOST" env-required:"true"`
 	User        string        `env:"USER" env-required:"true"`
 	Password    string        `env:"USER_PASSWORD" env-required:"true"`
+	Role        string        `env:"USER_ROLE" env-default:"admin"`
 	Timeout     time.Duration `env:"TIMEOUT" env-default:"4s"`
 	IdleTimeout time.Duration `env:"IDLE_TIMEOUT" env-default:"60s"`
 }
 
+// Log configures the service logger. An empty Level uses the default of
+// the environment: debug for local and dev, info otherwise.
+type Log struct {
+	Format             string   `env:"LOG_FORMAT" env-default:"text"`
+	Level              string   `env:"LOG_LEVEL"`
+	RedactKeys         []string `env:"LOG_REDACT_KEYS" env-default:"password,token,accessToken,refreshToken,key,apiKey,authorization,secret"`
+	MaxValueLength     int      `env:"LOG_MAX_VALUE_LENGTH" env-default:"256"`
+	SuccessSampleRatio float64  `env:"LOG_SUCCESS_SAMPLE_RATIO" env-default:"1"`
+}
+
+// Admin is the listener for operational endpoints such as /metrics and
+// /admin/log-level. With ADMIN_PORT unset they are served on the main port.
+type Admin struct {
+	Host string `env:"ADMIN_HOST"`
+	Port int    `env:"ADMIN_PORT" env-default:"0"`
+}
+
+// Health configures /readyz. ShutdownDelay is how long the service keeps
+// serving while reporting itself not ready before it stops accepting
+// connections.
+type Health struct {
+	CheckExternal bool          `env:"HEALTH_CHECK_EXTERNAL" env-default:"false"`
+	Timeout       time.Duration `env:"HEALTH_TIMEOUT" env-default:"2s"`
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" env-default:"5s"`
+}
+
+// Tracing selects the span exporter: none, otlp (configured by the standard
+// OTEL_EXPORTER_OTLP_* variables), stdout or file.
+type Tracing struct {
+	ServiceName string  `env:"TRACING_SERVICE_NAME" env-default:"songs-lib"`
+	Exporter    string  `env:"TRACING_EXPORTER" env-default:"none"`
+	File        string  `env:"TRACING_FILE" env-default:"traces.json"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+}
+
+// OpenAPI controls validation against the served spec. Invalid requests are
+// rejected with 400; invalid responses are only logged.
+type OpenAPI struct {
+	ValidateRequests  bool `env:"OPENAPI_VALIDATE_REQUESTS" env-default:"true"`
+	ValidateResponses bool `env:"OPENAPI_VALIDATE_RESPONSES" env-default:"false"`
+}
+
+type JWT struct {
+	Issuer     string            `env:"JWT_ISSUER" env-default:"songs-lib"`
+	SigningKID string            `env:"JWT_SIGNING_KID" env-default:"default"`
+	HMACKeys   map[string]string `env:"JWT_HMAC_KEYS"`
+	RSAKeys    map[string]string `env:"JWT_RSA_KEYS"`
+	AccessTTL  time.Duration     `env:"JWT_ACCESS_TTL" env-default:"15m"`
+	RefreshTTL time.Duration     `env:"JWT_REFRESH_TTL" env-default:"720h"`
+}
+
+type RBAC struct {
+	PolicyFile    string            `env:"RBAC_POLICY_FILE"`
+	AnonymousRole string            `env:"RBAC_ANONYMOUS_ROLE" env-default:"viewer"`
+	Users         map[string]string `env:"USERS"`
+	UserRoles     map[string]string `env:"USERS_ROLES"`
+}
+
+type RateLimit struct {
+	Enabled     bool          `env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	ReadRPS     float64       `env:"RATE_LIMIT_READ_RPS" env-default:"20"`
+	ReadBurst   int           `env:"RATE_LIMIT_READ_BURST" env-default:"40"`
+	WriteRPS    float64       `env:"RATE_LIMIT_WRITE_RPS" env-default:"2"`
+	WriteBurst  int           `env:"RATE_LIMIT_WRITE_BURST" env-default:"5"`
+	ExportRPS   float64       `env:"RATE_LIMIT_EXPORT_RPS" env-default:"0.1"`
+	ExportBurst int           `env:"RATE_LIMIT_EXPORT_BURST" env-default:"1"`
+	ReadQuota   int           `env:"QUOTA_READ_DAILY" env-default:"0"`
+	WriteQuota  int           `env:"QUOTA_WRITE_DAILY" env-default:"0"`
+	ExportQuota int           `env:"QUOTA_EXPORT_DAILY" env-default:"0"`
+	IdleTTL     time.Duration `env:"RATE_LIMIT_IDLE_TTL" env-default:"10m"`
+}
+
+// Suggest configures the suggestions of /suggest. Suggestions are cached
+// until the library changes or CacheTTL passes, which bounds how long view
+// counts take to reorder them. Views are saved every ViewsFlushInterval,
+// or only at shutdown when it is 0.
+type Suggest struct {
+	CacheSize          int           `env:"SUGGEST_CACHE_SIZE" env-default:"10000"`
+	CacheTTL           time.Duration `env:"SUGGEST_CACHE_TTL" env-default:"5m"`
+	Timeout            time.Duration `env:"SUGGEST_TIMEOUT" env-default:"300ms"`
+	ViewsFlushInterval time.Duration `env:"VIEWS_FLUSH_INTERVAL" env-default:"30s"`
+}
+
+// Similar configures /songs/{id}/similar. Songs are scored by the weighted
+// mean of the similarity of their lyrics, a shared artist and how close
+// their release years are. The index is kept in memory and rebuilt every
+// RebuildInterval to pick up changes made by other instances; 0 loads it
+// only at start.
+type Similar struct {
+	LyricsWeight    float64       `env:"SIMILAR_WEIGHT_LYRICS" env-default:"0.6"`
+	ArtistWeight    float64       `env:"SIMILAR_WEIGHT_ARTIST" env-default:"0.3"`
+	YearWeight      float64       `env:"SIMILAR_WEIGHT_YEAR" env-default:"0.1"`
+	YearWindow      int           `env:"SIMILAR_YEAR_WINDOW" env-default:"10"`
+	RebuildInterval time.Duration `env:"SIMILAR_REBUILD_INTERVAL" env-default:"1h"`
+}
+
 type Config struct {
-	Env           string     `env:"ENV" env-default:"local"`
-	ExternalAPI   string     `env:"EXTERNAL_API" env-required:"true"`
-	PageSizeLimit int        `env:"PAGE_SIZE_LIMIT" env-default:"20"`
-	DB            Database   `env:",embedded"`
-	HTTPServer    HTTPServer `env:",embedded"`
+	Env            string        `env:"ENV" env-default:"local"`
+	ExternalAPI    string        `env:"EXTERNAL_API" env-required:"true"`
+	PageSizeLimit  int           `env:"PAGE_SIZE_LIMIT" env-default:"20"`
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	DB             Database      `env:",embedded"`
+	HTTPServer     HTTPServer    `env:",embedded"`
+	Admin          Admin         `env:",embedded"`
+	Log            Log           `env:",embedded"`
+	Tracing        Tracing       `env:",embedded"`
+	Health         Health        `env:",embedded"`
+	OpenAPI        OpenAPI       `env:",embedded"`
+	JWT            JWT           `env:",embedded"`
+	RBAC           RBAC          `env:",embedded"`
+	RateLimit      RateLimit     `env:",embedded"`
+	Suggest        Suggest       `env:",embedded"`
+	Similar        Similar       `env:",embedded"`
 }
 
 func MustLoad() *Config {
@@ -45,5 +156,11 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read .env file config: %s", err)
 	}
 
+	// A bucket that never refills would reject a client for good once its
+	// burst is spent.
+	if rl := cfg.RateLimit; rl.Enabled && (rl.ReadRPS <= 0 || rl.WriteRPS <= 0 || rl.ExportRPS <= 0) {
+		log.Fatalf("rate limits must be positive, set RATE_LIMIT_ENABLED=false to disable them")
+	}
+
 	return &cfg
 }
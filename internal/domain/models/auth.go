@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+const (
+	AuthMethodAnonymous = "anonymous"
+	AuthMethodBasic     = "basic"
+	AuthMethodBearer    = "bearer"
+	AuthMethodAPIKey    = "apikey"
+)
+
+type Principal struct {
+	Subject   string
+	Method    string
+	Roles     []string
+	Scopes    []string
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+type User struct {
+	Password string
+	Roles    []string
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+}
+
+type RefreshToken struct {
+	Hash      string    `db:"token_hash"`
+	Subject   string    `db:"subject"`
+	ExpiresAt time.Time `db:"expires_at"`
+	Revoked   bool      `db:"revoked"`
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowedIps,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
@@ -1,26 +1,295 @@
 package models
 
+import "time"
+
+// SongData is a song of the library. Language is detected from the lyrics
+// on every change of them, with LanguageConfidence from 0 to 1.
+// CanonicalKey and Fingerprint find duplicates of the song and are kept
+// up to date by the service.
 type SongData struct {
-	ID          int    `json:"id,omitempty" db:"id"`
-	Group       string `json:"group,omitempty" db:"group"`
-	Song        string `json:"song,omitempty" db:"song"`
-	ReleaseDate string `json:"releaseDate,omitempty" db:"release_date"`
-	Text        string `json:"text,omitempty" db:"lyrics"`
-	Link        string `json:"link,omitempty" db:"link"`
+	ID                 int          `json:"id,omitempty" db:"id"`
+	Group              string       `json:"group,omitempty" db:"group"`
+	Song               string       `json:"song,omitempty" db:"song"`
+	ReleaseDate        string       `json:"releaseDate,omitempty" db:"release_date"`
+	Text               string       `json:"text,omitempty" db:"lyrics"`
+	Link               string       `json:"link,omitempty" db:"link"`
+	Language           string       `json:"language,omitempty" db:"language"`
+	LanguageConfidence float64      `json:"languageConfidence,omitempty" db:"language_confidence"`
+	CanonicalKey       string       `json:"-" db:"canonical_key"`
+	Fingerprint        *Fingerprint `json:"-" db:"-"`
 }
 
+// UpdateSongData holds the fields to change. Language, CanonicalKey and
+// Fingerprint are not set by clients: they follow Text and the identity
+// of the song.
 type UpdateSongData struct {
-	Group       *string `json:"group,omitempty"`
-	Song        *string `json:"song,omitempty"`
-	ReleaseDate *string `json:"releaseDate,omitempty"`
-	Text        *string `json:"text,omitempty"`
-	Link        *string `json:"link,omitempty"`
+	Group        *string           `json:"group,omitempty"`
+	Song         *string           `json:"song,omitempty"`
+	ReleaseDate  *string           `json:"releaseDate,omitempty"`
+	Text         *string           `json:"text,omitempty"`
+	Link         *string           `json:"link,omitempty"`
+	Language     *DetectedLanguage `json:"-"`
+	CanonicalKey *string           `json:"-"`
+	Fingerprint  *Fingerprint      `json:"-"`
 }
 
+// FilterSongData narrows a list of songs. Group and Song match names in
+// either Cyrillic or Latin spelling, and Query searches names and lyrics.
 type FilterSongData struct {
 	Group       *string `json:"group,omitempty"`
 	Song        *string `json:"song,omitempty"`
 	ReleaseDate *string `json:"releaseDate,omitempty"`
+	Language    *string `json:"language,omitempty"`
+	Query       *string `json:"q,omitempty"`
 	Page        int
 	PerPage     int
 }
+
+// Section is a typed part of the lyrics. Number counts sections of the same
+// type from 1, so the second verse is {Type: "verse", Number: 2} however
+// many choruses come before it. Label is the marker the lyrics gave it,
+// e.g. "Verse 2: Matt Bellamy".
+type Section struct {
+	Type   string `json:"type"`
+	Number int    `json:"number"`
+	Label  string `json:"label,omitempty"`
+	Text   string `json:"text"`
+}
+
+// TimedLine is a line of synced lyrics that starts OffsetMS milliseconds
+// into the song. An empty Text is a pause between verses.
+type TimedLine struct {
+	OffsetMS int    `json:"offsetMs"`
+	Text     string `json:"text"`
+}
+
+// Lyrics is the plain text of a song and, when it has synced lyrics, the
+// timed lines the text was derived from. Languages lists the language
+// versions of the song without their text.
+type Lyrics struct {
+	Text      string
+	Lines     []TimedLine
+	Languages []Translation
+}
+
+// ActiveLine is the line being sung at a playback offset. Line is nil
+// before the first line starts, and NextOffsetMS is nil after the last one.
+type ActiveLine struct {
+	Index        int        `json:"index"`
+	Line         *TimedLine `json:"line,omitempty"`
+	NextOffsetMS *int       `json:"nextOffsetMs,omitempty"`
+}
+
+// Translation is the lyrics of a song in one language. Exactly one
+// language of a song may be the Original; the others are translations.
+type Translation struct {
+	Lang     string `json:"lang"`
+	Original bool   `json:"original"`
+	Text     string `json:"text,omitempty"`
+}
+
+// VerseText is a verse in the language it was negotiated in. Lang is empty
+// when the song has no language versions, and Warning is set when the
+// verses of the translation do not line up with those of the original.
+type VerseText struct {
+	Text    string `json:"text"`
+	Lang    string `json:"lang,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// ChordSheet is a song with chords, split into the same sections as its
+// lyrics. Key and Capo are those the chords are shown for, after
+// Transpose semitones were applied.
+type ChordSheet struct {
+	Title     string         `json:"title,omitempty"`
+	Artist    string         `json:"artist,omitempty"`
+	Key       string         `json:"key,omitempty"`
+	Capo      int            `json:"capo"`
+	Transpose int            `json:"transpose"`
+	Sections  []ChordSection `json:"sections"`
+}
+
+// ChordSection is a section of a chord sheet, numbered like Section.
+type ChordSection struct {
+	Type   string      `json:"type"`
+	Number int         `json:"number"`
+	Label  string      `json:"label,omitempty"`
+	Lines  []ChordLine `json:"lines"`
+}
+
+// ChordLine is a line of lyrics with the chords played over it.
+type ChordLine struct {
+	Lyrics string          `json:"lyrics"`
+	Chords []ChordPosition `json:"chords,omitempty"`
+}
+
+// ChordPosition is a chord that starts at the Position-th character of the
+// lyrics line, counted from 0.
+type ChordPosition struct {
+	Chord    string `json:"chord"`
+	Position int    `json:"position"`
+}
+
+// ChordOptions selects how a chord sheet is shown. A nil Capo keeps the
+// capo of the sheet, and a Verse of 0 shows the whole sheet.
+type ChordOptions struct {
+	Transpose int
+	Capo      *int
+	Verse     int
+}
+
+// WordCount is a word and how many times it occurs.
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// LyricsStats describes the lyrics of a song in language Lang. ChorusRatio
+// is the share of lines sung in choruses; the times are estimates in
+// seconds.
+type LyricsStats struct {
+	Lang           string      `json:"lang,omitempty"`
+	Lines          int         `json:"lines"`
+	Words          int         `json:"words"`
+	UniqueWords    int         `json:"uniqueWords"`
+	Verses         int         `json:"verses"`
+	Choruses       int         `json:"choruses"`
+	ChorusRatio    float64     `json:"chorusRatio"`
+	ReadingSeconds int         `json:"readingSeconds"`
+	SingingSeconds int         `json:"singingSeconds"`
+	TopWords       []WordCount `json:"topWords"`
+}
+
+// CatalogStats describes the lyrics of the songs matching a filter.
+type CatalogStats struct {
+	Songs   int           `json:"songs"`
+	Words   int           `json:"words"`
+	Artists []ArtistStats `json:"artists"`
+	Years   []YearStats   `json:"years"`
+}
+
+// ArtistStats describes the lyrics of the songs of one group.
+type ArtistStats struct {
+	Group    string      `json:"group" db:"group"`
+	Songs    int         `json:"songs" db:"songs"`
+	Words    int         `json:"words" db:"words"`
+	TopWords []WordCount `json:"topWords"`
+}
+
+// YearStats describes the songs released in Year. Songs without a release
+// date are left out.
+type YearStats struct {
+	Year         int     `json:"year" db:"year"`
+	Songs        int     `json:"songs" db:"songs"`
+	AverageWords float64 `json:"averageWords" db:"average_words"`
+	AverageLines float64 `json:"averageLines" db:"average_lines"`
+}
+
+// DetectedLanguage is the language lyrics were detected to be in, as a base
+// BCP-47 tag, and the confidence of the guess from 0 to 1. Lang is empty
+// when the lyrics are too short to tell.
+type DetectedLanguage struct {
+	Lang       string  `json:"language"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Fingerprint is the MinHash signature of the lyrics of a song and the
+// hashes of its bands, which songs with similar lyrics likely share. The
+// zero Fingerprint stands for lyrics without words.
+type Fingerprint struct {
+	MinHash []int64
+	Bands   []int64
+}
+
+// SongRef identifies a song by its ID and names.
+type SongRef struct {
+	ID    int    `json:"id" db:"id"`
+	Group string `json:"group" db:"group"`
+	Song  string `json:"song" db:"song"`
+}
+
+// DuplicatePair is two songs that are likely the same. SameKey is set when
+// their names normalize to the same key, and LyricsSimilarity estimates
+// the share of lyrics they have in common, from 0 to 1.
+type DuplicatePair struct {
+	First            SongRef `json:"first"`
+	Second           SongRef `json:"second"`
+	SameKey          bool    `json:"sameKey"`
+	LyricsSimilarity float64 `json:"lyricsSimilarity"`
+}
+
+// Revision actions: the song absorbed RelatedSongID, or was merged into it
+// and deleted.
+const (
+	RevisionMerge      = "merge"
+	RevisionMergedInto = "merged_into"
+)
+
+// Revision is the state of a song before Action was applied to it by
+// Actor.
+type Revision struct {
+	ID            int          `json:"id"`
+	SongID        int          `json:"songId"`
+	Action        string       `json:"action"`
+	RelatedSongID int          `json:"relatedSongId,omitempty"`
+	Actor         string       `json:"actor,omitempty"`
+	CreatedAt     time.Time    `json:"createdAt"`
+	Snapshot      SongSnapshot `json:"snapshot"`
+}
+
+// SongSnapshot is a song with its language versions and chord sheet as it
+// was at a revision.
+type SongSnapshot struct {
+	Group        string        `json:"group"`
+	Song         string        `json:"song"`
+	ReleaseDate  string        `json:"releaseDate,omitempty"`
+	Text         string        `json:"text,omitempty"`
+	Link         string        `json:"link,omitempty"`
+	Language     string        `json:"language,omitempty"`
+	Translations []Translation `json:"translations,omitempty"`
+	ChordPro     string        `json:"chordpro,omitempty"`
+}
+
+// Suggestion types.
+const (
+	SuggestGroup = "group"
+	SuggestSong  = "song"
+	SuggestAll   = "all"
+)
+
+// Suggestion is a group name or song title that matches what a user is
+// typing. IDs are the songs it stands for, most viewed first, and
+// Popularity is how many times they were viewed. Match tells how the name
+// matched: its start, the start of one of its words, or similar spelling.
+type Suggestion struct {
+	Type       string `json:"type"`
+	Text       string `json:"text"`
+	IDs        []int  `json:"ids"`
+	Popularity int64  `json:"popularity"`
+	Match      string `json:"match"`
+}
+
+// Similarity signals.
+const (
+	SignalLyrics = "lyrics"
+	SignalArtist = "artist"
+	SignalYear   = "year"
+)
+
+// SimilarSong is a song similar to another. Score is from 0 to 1 and
+// Reasons are the signals it matched by, strongest first.
+type SimilarSong struct {
+	Song    SongRef            `json:"song"`
+	Score   float64            `json:"score"`
+	Reasons []SimilarityReason `json:"reasons"`
+}
+
+// SimilarityReason is a signal a similar song matched by. Score is what it
+// added to the score of the song, and Words are the most telling words the
+// lyrics share.
+type SimilarityReason struct {
+	Signal string   `json:"signal"`
+	Score  float64  `json:"score"`
+	Detail string   `json:"detail"`
+	Words  []string `json:"words,omitempty"`
+}
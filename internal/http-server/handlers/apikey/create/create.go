@@ -0,0 +1,97 @@
+package createhandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	Name       string     `json:"name" validate:"required"`
+	Scopes     []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	AllowedIPs []string   `json:"allowedIps,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	Key    string        `json:"key"`
+	APIKey models.APIKey `json:"apiKey"`
+}
+
+type KeyCreator interface {
+	Create(ctx context.Context, name string, scopes []string, expiresAt *time.Time, allowedIPs []string) (models.APIKey, string, error)
+}
+
+func New(log *slog.Logger, keyCreator KeyCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
+
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
+
+			problem.Validation(w, r, err)
+
+			return
+		}
+
+		key, plain, err := keyCreator.Create(r.Context(), req.Name, req.Scopes, req.ExpiresAt, req.AllowedIPs)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to create api key", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("api key created", slog.Int("id", key.ID))
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Key:      plain,
+			APIKey:   key,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package listhandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	APIKeys []models.APIKey `json:"apiKeys"`
+}
+
+type KeysProvider interface {
+	APIKeys(ctx context.Context) ([]models.APIKey, error)
+}
+
+func New(log *slog.Logger, keysProvider KeysProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.apikey.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		keys, err := keysProvider.APIKeys(r.Context())
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to list api keys", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("api keys listed", slog.Int("count", len(keys)))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			APIKeys:  keys,
+		})
+	}
+}
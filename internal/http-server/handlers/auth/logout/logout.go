@@ -0,0 +1,72 @@
+package logouthandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type LogoutProvider interface {
+	Logout(ctx context.Context, principal models.Principal, refreshToken string) error
+}
+
+func New(log *slog.Logger, logoutProvider LogoutProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.logout.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		p, ok := principal.FromContext(r.Context())
+		if !ok {
+			log.Error("principal is missing in request context")
+
+			problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		if err := logoutProvider.Logout(r.Context(), p, req.RefreshToken); err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to logout", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("user logged out", slog.String("subject", p.Subject))
+
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,88 @@
+package refreshhandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+	models.TokenPair
+}
+
+type TokenRefresher interface {
+	Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error)
+}
+
+func New(log *slog.Logger, tokenRefresher TokenRefresher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.auth.refresh.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
+
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
+
+			problem.Validation(w, r, err)
+
+			return
+		}
+
+		pair, err := tokenRefresher.Refresh(r.Context(), req.RefreshToken)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to refresh token", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("token refreshed")
+
+		render.JSON(w, r, Response{
+			Response:  response.OK(),
+			TokenPair: pair,
+		})
+	}
+}
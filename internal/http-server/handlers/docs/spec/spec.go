@@ -0,0 +1,11 @@
+package spechandler
+
+import "net/http"
+
+// New serves the OpenAPI spec the service validates requests against.
+func New(spec []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write(spec)
+	}
+}
@@ -0,0 +1,44 @@
+package uihandler
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// swaggerUIVersion is the swagger-ui-dist release the page loads from the CDN.
+const swaggerUIVersion = "5.17.14"
+
+var page = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Songs Library API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// New serves a Swagger UI page for the spec at specURL.
+func New(specURL string) http.HandlerFunc {
+	data := struct {
+		Version string
+		SpecURL string
+	}{
+		Version: swaggerUIVersion,
+		SpecURL: specURL,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = page.Execute(w, data)
+	}
+}
@@ -0,0 +1,17 @@
+package livehandler
+
+import (
+	"net/http"
+
+	"effective_mobile/internal/lib/api/response"
+
+	"github.com/go-chi/render"
+)
+
+// New reports that the process is up and serving requests. It checks no
+// dependencies, so a failing database never gets the process restarted.
+func New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,51 @@
+package readyhandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/health"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Checks map[string]health.Result `json:"checks"`
+}
+
+type ReadinessChecker interface {
+	Ready(ctx context.Context) (map[string]health.Result, bool)
+}
+
+func New(log *slog.Logger, checker ReadinessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.ready.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		checks, ready := checker.Ready(r.Context())
+		if !ready {
+			log.Warn("service is not ready", slog.Any("checks", checks))
+
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, Response{
+				Response: response.Response{Status: response.StatusError, Error: "not ready"},
+				Checks:   checks,
+			})
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Checks:   checks,
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package gethandler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/lib/api/response"
+
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Level string `json:"level"`
+}
+
+type Leveler interface {
+	Level() slog.Level
+}
+
+func New(leveler Leveler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Level:    leveler.Level().String(),
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package sethandler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Request struct {
+	Level string `json:"level" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+	Level string `json:"level"`
+}
+
+type LevelSetter interface {
+	Level() slog.Level
+	Set(level slog.Level)
+}
+
+// New changes the level of the service logger at runtime. The level is one
+// of debug, info, warn and error, optionally with an offset such as info+2.
+func New(log *slog.Logger, levelSetter LevelSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.loglevel.set.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
+
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
+
+			problem.Validation(w, r, err)
+
+			return
+		}
+
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+			log.Error("invalid log level", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "level must be one of debug, info, warn, error")
+
+			return
+		}
+
+		previous := levelSetter.Level()
+		levelSetter.Set(level)
+
+		log.Warn("log level changed",
+			slog.String("from", previous.String()),
+			slog.String("to", level.String()),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Level:    level.String(),
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package activelinehandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	models.ActiveLine
+}
+
+type ActiveLineProvider interface {
+	ActiveLine(ctx context.Context, id, offsetMS int) (models.ActiveLine, error)
+}
+
+func New(log *slog.Logger, lineProvider ActiveLineProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.activeline.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil {
+			log.Info("invalid offset", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidOffset, "offset must be an integer number of milliseconds")
+
+			return
+		}
+
+		line, err := lineProvider.ActiveLine(r.Context(), id, offset)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to get active line", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("active line found", slog.Int("offset", offset), slog.Int("index", line.Index))
+
+		render.JSON(w, r, Response{
+			Response:   response.OK(),
+			ActiveLine: line,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package catalogstatshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	models.CatalogStats
+}
+
+type CatalogStatsProvider interface {
+	CatalogStats(ctx context.Context, filter models.FilterSongData, top int) (models.CatalogStats, error)
+}
+
+func New(log *slog.Logger, statsProvider CatalogStatsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.catalogstats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		filter := models.FilterSongData{
+			Group:       stringPtr(query.Get("group")),
+			Song:        stringPtr(query.Get("song")),
+			ReleaseDate: stringPtr(query.Get("releaseDate")),
+			Language:    stringPtr(query.Get("language")),
+			Query:       stringPtr(query.Get("q")),
+		}
+
+		var top int
+		if value := query.Get("top"); value != "" {
+			var err error
+
+			top, err = strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid top", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidTop, "top must be an integer")
+
+				return
+			}
+		}
+
+		stats, err := statsProvider.CatalogStats(r.Context(), filter, top)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to get catalog stats", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("catalog stats computed", slog.Int("songs", stats.Songs))
+
+		render.JSON(w, r, Response{
+			Response:     response.OK(),
+			CatalogStats: stats,
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
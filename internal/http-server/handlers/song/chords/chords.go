@@ -0,0 +1,129 @@
+package chordshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/chords"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Formats of the chord sheet.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Response struct {
+	response.Response
+	models.ChordSheet
+}
+
+type ChordsProvider interface {
+	Chords(ctx context.Context, id int, opts models.ChordOptions) (models.ChordSheet, error)
+}
+
+// New serves the chord sheet of a song as JSON or as text with chords above
+// the lyrics, transposed by the transpose query parameter and for the capo
+// of the capo parameter.
+func New(log *slog.Logger, chordsProvider ChordsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.chords.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		query := r.URL.Query()
+
+		format := query.Get("format")
+		if format == "" {
+			format = FormatJSON
+		}
+
+		if format != FormatJSON && format != FormatText {
+			log.Info("invalid format", slog.String("format", format))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "format must be one of json, text")
+
+			return
+		}
+
+		var opts models.ChordOptions
+
+		for name, target := range map[string]*int{"transpose": &opts.Transpose, "verse": &opts.Verse} {
+			value := query.Get(name)
+			if value == "" {
+				continue
+			}
+
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid parameter", slog.String("name", name), sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, name+" must be an integer")
+
+				return
+			}
+
+			*target = n
+		}
+
+		if value := query.Get("capo"); value != "" {
+			capo, err := strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid capo", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidCapo, "capo must be an integer")
+
+				return
+			}
+
+			opts.Capo = &capo
+		}
+
+		sheet, err := chordsProvider.Chords(r.Context(), id, opts)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to get chords", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("chords found", slog.Int("transpose", sheet.Transpose), slog.Int("capo", sheet.Capo))
+
+		if format == FormatText {
+			render.PlainText(w, r, chords.Text(sheet))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:   response.OK(),
+			ChordSheet: sheet,
+		})
+	}
+}
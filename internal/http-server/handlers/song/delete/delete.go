@@ -1,14 +1,15 @@
 package deletehandler
 
 import (
-	"errors"
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	"effective_mobile/internal/lib/api/problem"
 	"effective_mobile/internal/lib/api/response"
 	"effective_mobile/internal/lib/logger/sl"
-	"effective_mobile/internal/storage"
+	"effective_mobile/internal/lib/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -16,7 +17,7 @@ import (
 )
 
 type SongDeleter interface {
-	DeleteSong(id int) error
+	DeleteSong(ctx context.Context, id int) error
 }
 
 func New(log *slog.Logger, songDeleter SongDeleter) http.HandlerFunc {
@@ -26,13 +27,14 @@ func New(log *slog.Logger, songDeleter SongDeleter) http.HandlerFunc {
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		idString := chi.URLParam(r, "id")
 		if idString == "" {
 			log.Error("missing id parameter in path")
 
-			response.Error(w, r, http.StatusBadRequest, "missing id in path")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "missing id in path")
 
 			return
 		}
@@ -41,25 +43,18 @@ func New(log *slog.Logger, songDeleter SongDeleter) http.HandlerFunc {
 		if err != nil {
 			log.Error("invalid id format", sl.Err(err))
 
-			response.Error(w, r, http.StatusBadRequest, "invalid id format")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
 
 			return
 		}
 
 		log.Info("id decoded", slog.Any("id", id))
 
-		if err := songDeleter.DeleteSong(id); err != nil {
-			if errors.Is(err, storage.ErrSongNotFound) {
-				log.Info("song not found", slog.String("id", idString))
+		if err := songDeleter.DeleteSong(r.Context(), id); err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to delete song", sl.Err(err))
 
-				response.Error(w, r, http.StatusNotFound, "song not found")
-
-				return
-			}
-
-			log.Error("failed to delete song", sl.Err(err))
-
-			response.Error(w, r, http.StatusInternalServerError, "failed to delete song")
+			problem.Write(w, r, p)
 
 			return
 		}
@@ -0,0 +1,55 @@
+package deletechordshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type ChordsDeleter interface {
+	DeleteChords(ctx context.Context, id int) error
+}
+
+func New(log *slog.Logger, chordsDeleter ChordsDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.deletechords.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		if err := chordsDeleter.DeleteChords(r.Context(), id); err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to delete chords", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("chords deleted", slog.Int("id", id))
+
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,85 @@
+package duplicateshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Duplicates []models.DuplicatePair `json:"duplicates"`
+}
+
+type DuplicatesProvider interface {
+	Duplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicatePair, error)
+}
+
+func New(log *slog.Logger, duplicatesProvider DuplicatesProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.duplicates.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		var threshold float64
+		if value := query.Get("threshold"); value != "" {
+			var err error
+
+			threshold, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				log.Info("invalid threshold", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidThreshold, "threshold must be a number")
+
+				return
+			}
+		}
+
+		var limit int
+		if value := query.Get("limit"); value != "" {
+			var err error
+
+			limit, err = strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid limit", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidLimit, "limit must be an integer")
+
+				return
+			}
+		}
+
+		pairs, err := duplicatesProvider.Duplicates(r.Context(), threshold, limit)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to find duplicates", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("duplicates found", slog.Int("pairs", len(pairs)))
+
+		render.JSON(w, r, Response{
+			Response:   response.OK(),
+			Duplicates: pairs,
+		})
+	}
+}
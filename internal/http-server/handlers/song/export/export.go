@@ -0,0 +1,70 @@
+package exporthandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Songs []models.SongData `json:"songs"`
+}
+
+type SongsExporter interface {
+	ExportSongs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error)
+}
+
+func New(log *slog.Logger, songsExporter SongsExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.export.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		filter := models.FilterSongData{
+			Group:       stringPtr(query.Get("group")),
+			Song:        stringPtr(query.Get("song")),
+			ReleaseDate: stringPtr(query.Get("releaseDate")),
+			Language:    stringPtr(query.Get("language")),
+			Query:       stringPtr(query.Get("q")),
+		}
+
+		songs, err := songsExporter.ExportSongs(r.Context(), filter)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to export songs", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("songs exported", slog.Int("count", len(songs)))
+
+		w.Header().Set("Content-Disposition", `attachment; filename="songs.json"`)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Songs:    songs,
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
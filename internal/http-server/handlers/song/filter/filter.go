@@ -1,15 +1,16 @@
 package filterhandler
 
 import (
-	"errors"
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
 
 	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
 	"effective_mobile/internal/lib/api/response"
 	"effective_mobile/internal/lib/logger/sl"
-	"effective_mobile/internal/storage"
+	"effective_mobile/internal/lib/tracing"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -21,7 +22,7 @@ type Response struct {
 }
 
 type SongsProvider interface {
-	Songs(models.FilterSongData) ([]models.SongData, error)
+	Songs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error)
 }
 
 func New(log *slog.Logger, songsProvider SongsProvider, pageSizeLimit int) http.HandlerFunc {
@@ -31,6 +32,7 @@ func New(log *slog.Logger, songsProvider SongsProvider, pageSizeLimit int) http.
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		query := r.URL.Query()
@@ -39,23 +41,18 @@ func New(log *slog.Logger, songsProvider SongsProvider, pageSizeLimit int) http.
 			Group:       stringPtr(query.Get("group")),
 			Song:        stringPtr(query.Get("song")),
 			ReleaseDate: stringPtr(query.Get("releaseDate")),
+			Language:    stringPtr(query.Get("language")),
+			Query:       stringPtr(query.Get("q")),
 			Page:        intOrDefault(query.Get("page"), 1),
 			PerPage:     intOrDefault(query.Get("per_page"), pageSizeLimit),
 		}
 
-		songs, err := songsProvider.Songs(filter)
+		songs, err := songsProvider.Songs(r.Context(), filter)
 		if err != nil {
-			if errors.Is(err, storage.ErrSongNotFound) {
-				log.Info("songs not found")
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to find songs", sl.Err(err))
 
-				response.Error(w, r, http.StatusNotFound, "songs not found")
-
-				return
-			}
-
-			log.Error("failed to find songs", sl.Err(err))
-
-			response.Error(w, r, http.StatusInternalServerError, "failed to find songs")
+			problem.Write(w, r, p)
 
 			return
 		}
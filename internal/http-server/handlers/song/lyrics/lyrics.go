@@ -0,0 +1,107 @@
+package lyricshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/lyrics"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Formats of the lyrics.
+const (
+	FormatJSON = "json"
+	FormatLRC  = "lrc"
+	FormatText = "text"
+)
+
+type Response struct {
+	response.Response
+	Synced    bool                 `json:"synced"`
+	Text      string               `json:"text"`
+	Lines     []models.TimedLine   `json:"lines,omitempty"`
+	Languages []models.Translation `json:"languages,omitempty"`
+}
+
+type LyricsProvider interface {
+	Lyrics(ctx context.Context, id int) (models.Lyrics, error)
+}
+
+// New serves the lyrics of a song as JSON, as LRC or as plain text, chosen
+// by the format query parameter. LRC is only served for synced lyrics.
+func New(log *slog.Logger, lyricsProvider LyricsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.lyrics.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatJSON
+		}
+
+		if format != FormatJSON && format != FormatLRC && format != FormatText {
+			log.Info("invalid format", slog.String("format", format))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "format must be one of lrc, json, text")
+
+			return
+		}
+
+		songLyrics, err := lyricsProvider.Lyrics(r.Context(), id)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to get lyrics", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("lyrics found", slog.String("format", format), slog.Int("lines", len(songLyrics.Lines)))
+
+		switch format {
+		case FormatLRC:
+			if len(songLyrics.Lines) == 0 {
+				problem.Respond(w, r, http.StatusNotFound, problem.CodeLyricsNotSynced, "song has no synced lyrics")
+
+				return
+			}
+
+			render.PlainText(w, r, lyrics.FormatLRC(songLyrics.Lines))
+		case FormatText:
+			render.PlainText(w, r, songLyrics.Text)
+		default:
+			render.JSON(w, r, Response{
+				Response:  response.OK(),
+				Synced:    len(songLyrics.Lines) > 0,
+				Text:      songLyrics.Text,
+				Lines:     songLyrics.Lines,
+				Languages: songLyrics.Languages,
+			})
+		}
+	}
+}
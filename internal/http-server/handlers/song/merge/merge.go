@@ -0,0 +1,100 @@
+package mergehandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request names the song the song of the path is merged into.
+type Request struct {
+	Into int `json:"into" validate:"required,min=1"`
+}
+
+type Response struct {
+	response.Response
+	Song models.SongData `json:"song"`
+}
+
+type SongMerger interface {
+	MergeSong(ctx context.Context, id, into int) (models.SongData, error)
+}
+
+func New(log *slog.Logger, songMerger SongMerger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.merge.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
+
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
+
+			problem.Validation(w, r, err)
+
+			return
+		}
+
+		merged, err := songMerger.MergeSong(r.Context(), id, req.Into)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to merge song", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("song merged", slog.Int("id", id), slog.Int("into", req.Into))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Song:     merged,
+		})
+	}
+}
@@ -1,20 +1,20 @@
 package savehandler
 
 import (
+	"context"
 	"errors"
-	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 
+	"effective_mobile/internal/lib/api/problem"
 	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
 	"effective_mobile/internal/lib/logger/sl"
-	"effective_mobile/internal/service"
-	"effective_mobile/internal/storage"
+	"effective_mobile/internal/lib/tracing"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 )
 
 type Request struct {
@@ -34,7 +34,7 @@ type SongDetail struct {
 }
 
 type SongSaver interface {
-	SaveSong(group, song string) (int, error)
+	SaveSong(ctx context.Context, group, song string) (int, error)
 }
 
 func New(log *slog.Logger, songSaver SongSaver) http.HandlerFunc {
@@ -44,6 +44,7 @@ func New(log *slog.Logger, songSaver SongSaver) http.HandlerFunc {
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		var req Request
@@ -52,7 +53,7 @@ func New(log *slog.Logger, songSaver SongSaver) http.HandlerFunc {
 		if errors.Is(err, io.EOF) {
 			log.Error("request body is empty")
 
-			response.Error(w, r, http.StatusBadRequest, "empty request")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
 
 			return
 		}
@@ -60,42 +61,27 @@ func New(log *slog.Logger, songSaver SongSaver) http.HandlerFunc {
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			response.Error(w, r, http.StatusInternalServerError, "failed to decode request")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
 
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request", req))
 
-		if err := validator.New().Struct(req); err != nil {
-			validateErr := err.(validator.ValidationErrors)
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
 
-			log.Error("invalid request", sl.Err(err))
-
-			response.Error(w, r, http.StatusBadRequest, response.ValidationErrors(validateErr))
+			problem.Validation(w, r, err)
 
 			return
 		}
 
-		id, err := songSaver.SaveSong(req.Group, req.Song)
-		if errors.Is(err, storage.ErrSongExists) {
-			log.Info("song already exists", slog.String("song", fmt.Sprintf("%s - %s", req.Group, req.Song)))
-
-			response.Error(w, r, http.StatusBadRequest, "song already exists")
-
-			return
-		}
-		if errors.Is(err, service.ErrInvalidDateFormat) {
-			log.Info("externalAPI response invalid date format", slog.String("song", fmt.Sprintf("%s - %s", req.Group, req.Song)))
-
-			response.Error(w, r, http.StatusInternalServerError, "externalAPI server error")
-
-			return
-		}
+		id, err := songSaver.SaveSong(r.Context(), req.Group, req.Song)
 		if err != nil {
-			log.Error("failed to add song", sl.Err(err))
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to add song", sl.Err(err))
 
-			response.Error(w, r, http.StatusInternalServerError, "failed to add song")
+			problem.Write(w, r, p)
 
 			return
 		}
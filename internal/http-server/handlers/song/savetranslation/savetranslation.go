@@ -0,0 +1,112 @@
+package savetranslationhandler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/api/validate"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Request is the lyrics in the language of the path. The original may be
+// sent without text to only mark the language of the current lyrics.
+type Request struct {
+	Text     string `json:"text" validate:"required_unless=Original true"`
+	Original bool   `json:"original"`
+}
+
+type Response struct {
+	response.Response
+	Warning string `json:"warning,omitempty"`
+}
+
+type TranslationSaver interface {
+	SaveTranslation(ctx context.Context, id int, translation models.Translation) (string, error)
+}
+
+func New(log *slog.Logger, translationSaver TranslationSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.savetranslation.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		var req Request
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
+
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
+
+			return
+		}
+
+		if err := validate.Struct(req); err != nil {
+			log.Info("invalid request", sl.Err(err))
+
+			problem.Validation(w, r, err)
+
+			return
+		}
+
+		lang := chi.URLParam(r, "lang")
+
+		warning, err := translationSaver.SaveTranslation(r.Context(), id, models.Translation{
+			Lang:     lang,
+			Original: req.Original,
+			Text:     req.Text,
+		})
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to save translation", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		if warning != "" {
+			log.Warn("translation verses are not aligned", slog.Int("id", id), slog.String("warning", warning))
+		}
+
+		log.Info("translation saved", slog.Int("id", id), slog.String("lang", lang), slog.Bool("original", req.Original))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Warning:  warning,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package similarhandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Similar []models.SimilarSong `json:"similar"`
+}
+
+type SimilarProvider interface {
+	Similar(ctx context.Context, id, limit int) ([]models.SimilarSong, error)
+}
+
+func New(log *slog.Logger, similarProvider SimilarProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.similar.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		var limit int
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid limit", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidLimit, "limit must be an integer")
+
+				return
+			}
+		}
+
+		similar, err := similarProvider.Similar(r.Context(), id, limit)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to find similar songs", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("similar songs found", slog.Int("id", id), slog.Int("similar", len(similar)))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Similar:  similar,
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package statshandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	models.LyricsStats
+}
+
+type StatsProvider interface {
+	Stats(ctx context.Context, id int, lang string, top int) (models.LyricsStats, error)
+}
+
+func New(log *slog.Logger, statsProvider StatsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Err(err))
+
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
+
+			return
+		}
+
+		var top int
+		if value := r.URL.Query().Get("top"); value != "" {
+			top, err = strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid top", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidTop, "top must be an integer")
+
+				return
+			}
+		}
+
+		stats, err := statsProvider.Stats(r.Context(), id, r.URL.Query().Get("lang"), top)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to get lyrics stats", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Info("lyrics stats computed", slog.Int("words", stats.Words))
+
+		render.JSON(w, r, Response{
+			Response:    response.OK(),
+			LyricsStats: stats,
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package suggesthandler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/api/response"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Suggestions []models.Suggestion `json:"suggestions"`
+}
+
+type SuggestionsProvider interface {
+	Suggest(ctx context.Context, q, kind string, limit int) ([]models.Suggestion, error)
+}
+
+// New serves suggestions while a name is typed. Requests taking longer
+// than timeout are answered with an error, as their suggestions would come
+// too late to be shown.
+func New(log *slog.Logger, suggestionsProvider SuggestionsProvider, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.song.suggest.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		var limit int
+		if value := query.Get("limit"); value != "" {
+			var err error
+
+			limit, err = strconv.Atoi(value)
+			if err != nil {
+				log.Info("invalid limit", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidLimit, "limit must be an integer")
+
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		suggestions, err := suggestionsProvider.Suggest(ctx, query.Get("q"), query.Get("type"), limit)
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to suggest", sl.Err(err))
+
+			problem.Write(w, r, p)
+
+			return
+		}
+
+		log.Debug("suggestions found", slog.Int("suggestions", len(suggestions)))
+
+		render.JSON(w, r, Response{
+			Response:    response.OK(),
+			Suggestions: suggestions,
+		})
+	}
+}
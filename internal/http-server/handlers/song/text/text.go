@@ -1,15 +1,16 @@
 package texthandler
 
 import (
-	"errors"
+	"context"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
 	"effective_mobile/internal/lib/api/response"
 	"effective_mobile/internal/lib/logger/sl"
-	"effective_mobile/internal/service"
-	"effective_mobile/internal/storage"
+	"effective_mobile/internal/lib/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,11 +19,13 @@ import (
 
 type Response struct {
 	response.Response
-	Text string `json:"text,omitempty"`
+	Text    string `json:"text,omitempty"`
+	Lang    string `json:"lang,omitempty"`
+	Warning string `json:"warning,omitempty"`
 }
 
 type TextProvider interface {
-	Text(id, verse int) (string, error)
+	Text(ctx context.Context, id, verse int, lang, acceptLanguage string) (models.VerseText, error)
 }
 
 func New(log *slog.Logger, textProvider TextProvider) http.HandlerFunc {
@@ -32,13 +35,14 @@ func New(log *slog.Logger, textProvider TextProvider) http.HandlerFunc {
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		idString := chi.URLParam(r, "id")
 		if idString == "" {
 			log.Error("missing id parameter in path")
 
-			response.Error(w, r, http.StatusBadRequest, "missing id in path")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "missing id in path")
 
 			return
 		}
@@ -47,7 +51,7 @@ func New(log *slog.Logger, textProvider TextProvider) http.HandlerFunc {
 		if err != nil {
 			log.Error("invalid id format", sl.Err(err))
 
-			response.Error(w, r, http.StatusBadRequest, "invalid id format")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
 
 			return
 		}
@@ -57,43 +61,43 @@ func New(log *slog.Logger, textProvider TextProvider) http.HandlerFunc {
 		if err != nil {
 			log.Error("invalid verse number format", sl.Err(err))
 
-			response.Error(w, r, http.StatusBadRequest, "invalid verse number format")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidParameter, "verse must be an integer")
 
 			return
 		}
 
-		text, err := textProvider.Text(id, verseNum)
-		if err != nil {
-			if errors.Is(err, storage.ErrSongNotFound) {
-				log.Info("songs not found")
-
-				response.Error(w, r, http.StatusNotFound, "songs not found")
+		// The verse depends on Accept-Language, so caches must key on it.
+		w.Header().Add("Vary", "Accept-Language")
 
-				return
-			}
+		text, err := textProvider.Text(r.Context(), id, verseNum, r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+		if err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to find songs", sl.Err(err))
 
-			if errors.Is(err, service.ErrInvalidVerseNumber) {
-				response.Error(w, r, http.StatusBadRequest, "invalid verse parameter")
+			problem.Write(w, r, p)
 
-				return
-			}
+			return
+		}
 
-			log.Error("failed to find songs", sl.Err(err))
+		if text.Warning != "" {
+			log.Warn("translation verses are not aligned", slog.String("lang", text.Lang), slog.String("warning", text.Warning))
+		}
 
-			response.Error(w, r, http.StatusInternalServerError, "failed to find songs")
+		log.Info("verse founded", slog.String("lang", text.Lang))
 
-			return
+		if text.Lang != "" {
+			w.Header().Set("Content-Language", text.Lang)
 		}
 
-		log.Info("verse founded")
-
 		responseOK(w, r, text)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, text string) {
+func responseOK(w http.ResponseWriter, r *http.Request, text models.VerseText) {
 	render.JSON(w, r, Response{
 		Response: response.OK(),
-		Text:     text,
+		Text:     text.Text,
+		Lang:     text.Lang,
+		Warning:  text.Warning,
 	})
 }
@@ -1,6 +1,7 @@
 package updatehandler
 
 import (
+	"context"
 	"errors"
 	"io"
 	"log/slog"
@@ -8,10 +9,10 @@ import (
 	"strconv"
 
 	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
 	"effective_mobile/internal/lib/api/response"
 	"effective_mobile/internal/lib/logger/sl"
-	"effective_mobile/internal/service"
-	"effective_mobile/internal/storage"
+	"effective_mobile/internal/lib/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -19,7 +20,7 @@ import (
 )
 
 type SongUpdater interface {
-	UpdateSong(id int, updateSong models.UpdateSongData) error
+	UpdateSong(ctx context.Context, id int, updateSong models.UpdateSongData) error
 }
 
 func New(log *slog.Logger, songUpdater SongUpdater) http.HandlerFunc {
@@ -29,13 +30,14 @@ func New(log *slog.Logger, songUpdater SongUpdater) http.HandlerFunc {
 		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
 		idString := chi.URLParam(r, "id")
 		if idString == "" {
 			log.Error("missing id parameter in path")
 
-			response.Error(w, r, http.StatusBadRequest, "missing id in path")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "missing id in path")
 
 			return
 		}
@@ -44,7 +46,7 @@ func New(log *slog.Logger, songUpdater SongUpdater) http.HandlerFunc {
 		if err != nil {
 			log.Error("invalid id format", sl.Err(err))
 
-			response.Error(w, r, http.StatusBadRequest, "invalid id format")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidID, "id must be an integer")
 
 			return
 		}
@@ -57,7 +59,7 @@ func New(log *slog.Logger, songUpdater SongUpdater) http.HandlerFunc {
 		if errors.Is(err, io.EOF) {
 			log.Error("request body is empty")
 
-			response.Error(w, r, http.StatusBadRequest, "empty request")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeEmptyBody, "request body is empty")
 
 			return
 		}
@@ -65,41 +67,18 @@ func New(log *slog.Logger, songUpdater SongUpdater) http.HandlerFunc {
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			response.Error(w, r, http.StatusInternalServerError, "failed to decode request")
+			problem.Respond(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "request body is not valid JSON")
 
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request", req))
 
-		if err := songUpdater.UpdateSong(id, req); err != nil {
-			if errors.Is(err, storage.ErrSongNotFound) {
-				log.Info("song not found", slog.String("id", idString))
+		if err := songUpdater.UpdateSong(r.Context(), id, req); err != nil {
+			p := problem.FromError(err)
+			log.Log(r.Context(), p.LogLevel(), "failed to update song", sl.Err(err))
 
-				response.Error(w, r, http.StatusNotFound, "song not found")
-
-				return
-			}
-
-			if errors.Is(err, service.ErrEmptyUpdate) {
-				log.Info("empty update request", slog.String("date", *req.ReleaseDate))
-
-				response.Error(w, r, http.StatusBadRequest, "empty update request")
-
-				return
-			}
-
-			if errors.Is(err, service.ErrInvalidDateFormat) {
-				log.Info("invalid date format for update", slog.String("date", *req.ReleaseDate))
-
-				response.Error(w, r, http.StatusBadRequest, "invalid date format for update")
-
-				return
-			}
-
-			log.Error("failed to update song", sl.Err(err))
-
-			response.Error(w, r, http.StatusInternalServerError, "failed to update song")
+			problem.Write(w, r, p)
 
 			return
 		}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type Authenticator interface {
+	CheckCredentials(username, password string) (models.Principal, bool)
+	Authenticate(ctx context.Context, accessToken string) (models.Principal, error)
+	AuthenticateAPIKey(ctx context.Context, key string, ip net.IP) (models.Principal, error)
+}
+
+const apiKeyHeader = "X-API-Key"
+
+// New accepts HTTP Basic credentials, a Bearer access token or an API key in
+// the X-API-Key header and stores the authenticated principal in the
+// request context.
+func New(log *slog.Logger, realm string, authenticator Authenticator) func(next http.Handler) http.Handler {
+	return newMiddleware(log, realm, authenticator, nil)
+}
+
+// NewOptional behaves like New, but lets requests without credentials
+// through as an anonymous principal with anonymousRoles. Invalid credentials
+// are still rejected. With no anonymous roles it is the same as New.
+func NewOptional(log *slog.Logger, realm string, authenticator Authenticator, anonymousRoles []string) func(next http.Handler) http.Handler {
+	return newMiddleware(log, realm, authenticator, anonymousRoles)
+}
+
+func newMiddleware(log *slog.Logger, realm string, authenticator Authenticator, anonymousRoles []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		log.Info("auth middleware enabled")
+
+		challenge := fmt.Sprintf(`Bearer realm=%q, Basic realm=%q`, realm, realm)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
+			)
+
+			var p models.Principal
+			var ok bool
+
+			anonymous := r.Header.Get("Authorization") == "" && r.Header.Get(apiKeyHeader) == ""
+
+			if anonymous && len(anonymousRoles) > 0 {
+				p, ok = models.Principal{
+					Subject: models.AuthMethodAnonymous,
+					Method:  models.AuthMethodAnonymous,
+					Roles:   anonymousRoles,
+				}, true
+			} else {
+				p, ok = authenticate(log, r, authenticator)
+			}
+
+			if !ok {
+				w.Header().Add("WWW-Authenticate", challenge)
+
+				problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(principal.With(r.Context(), p)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func authenticate(log *slog.Logger, r *http.Request, authenticator Authenticator) (models.Principal, bool) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		p, err := authenticator.AuthenticateAPIKey(r.Context(), key, remoteIP(r))
+		if err != nil {
+			log.Info("api key authentication failed", sl.Err(err))
+
+			return models.Principal{}, false
+		}
+
+		return p, true
+	}
+
+	header := r.Header.Get("Authorization")
+
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		p, err := authenticator.Authenticate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			log.Info("bearer authentication failed", sl.Err(err))
+
+			return models.Principal{}, false
+		}
+
+		return p, true
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		p, ok := authenticator.CheckCredentials(username, password)
+		if !ok {
+			log.Info("basic authentication failed", slog.String("user", username))
+
+			return models.Principal{}, false
+		}
+
+		return p, true
+	}
+
+	return models.Principal{}, false
+}
+
+func remoteIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
@@ -0,0 +1,48 @@
+package authz
+
+import (
+	"log/slog"
+	"net/http"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/rbac"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type Authorizer interface {
+	Permits(principal models.Principal, perm rbac.Permission) bool
+}
+
+// Require rejects requests whose principal is not granted perm.
+// It must run after the auth middleware.
+func Require(log *slog.Logger, authorizer Authorizer, perm rbac.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/authz"),
+			slog.String("permission", string(perm)),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			p, ok := principal.FromContext(r.Context())
+			if !ok || !authorizer.Permits(p, perm) {
+				log.Info("permission denied",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("trace_id", tracing.TraceID(r.Context())),
+					slog.String("subject", p.Subject),
+				)
+
+				problem.Respond(w, r, http.StatusForbidden, problem.CodeForbidden, "forbidden")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
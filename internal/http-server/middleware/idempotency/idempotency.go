@@ -0,0 +1,218 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	replayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+	// storeTimeout bounds storing the outcome of a request, which outlives
+	// the request when its client gave up or the server timed it out.
+	storeTimeout = 5 * time.Second
+)
+
+type RecordStorage interface {
+	ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error
+	ReleaseIdempotencyKey(ctx context.Context, client, key string) error
+}
+
+// New makes requests carrying an Idempotency-Key safe to retry. The first
+// response for a principal and key is stored for ttl and replayed for every
+// retry with the same payload. Reusing the key with a different payload is
+// rejected with 422, and a retry racing the first request with 409.
+// Server errors and 401 and 403 responses are not stored, so the request
+// can be retried for real, e.g. once the client got the role it lacked.
+// It must run after the auth middleware, and after authorization.
+func New(log *slog.Logger, recordStorage RecordStorage, ttl time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/idempotency"),
+		)
+
+		log.Info("idempotency middleware enabled")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
+				slog.String("idempotency_key", key),
+			)
+
+			if len(key) > maxKeyLength {
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeIdempotencyKeyLength, "idempotency key is too long")
+
+				return
+			}
+
+			p, ok := principal.FromContext(r.Context())
+			if !ok {
+				log.Error("principal is missing in request context")
+
+				problem.Respond(w, r, http.StatusUnauthorized, problem.CodeUnauthorized, "unauthorized")
+
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				log.Error("failed to read request body", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeBadRequest, "failed to read request")
+
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r, body)
+
+			record, created, err := recordStorage.ReserveIdempotencyKey(r.Context(), models.IdempotencyRecord{
+				Client:      p.Subject,
+				Key:         key,
+				RequestHash: hash,
+				ExpiresAt:   time.Now().Add(ttl),
+			})
+			if err != nil {
+				log.Error("failed to reserve idempotency key", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusInternalServerError, problem.CodeInternal, "")
+
+				return
+			}
+
+			if !created {
+				replay(log, w, r, record, hash)
+
+				return
+			}
+
+			// Unless the response is stored, the key is released, also when
+			// the handler panics, so a retry is served for real instead of
+			// waiting for the key to expire.
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+
+				ctx, cancel := storeContext(r)
+				defer cancel()
+
+				if err := recordStorage.ReleaseIdempotencyKey(ctx, p.Subject, key); err != nil {
+					log.Error("failed to release idempotency key", sl.Err(err))
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if !storable(status) {
+				return
+			}
+
+			record.Status = status
+			record.ContentType = ww.Header().Get("Content-Type")
+			record.Body = buf.Bytes()
+
+			ctx, cancel := storeContext(r)
+			defer cancel()
+
+			if err := recordStorage.CompleteIdempotencyKey(ctx, record); err != nil {
+				log.Error("failed to store idempotent response", sl.Err(err))
+
+				return
+			}
+
+			stored = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// storable reports whether a response with status is the outcome of the
+// request rather than of when or by whom it was sent.
+func storable(status int) bool {
+	return status < http.StatusInternalServerError && status != http.StatusUnauthorized && status != http.StatusForbidden
+}
+
+// storeContext returns the context the outcome of r is stored with. It is
+// not canceled with r, as a client that timed out is the one to retry.
+func storeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(r.Context()), storeTimeout)
+}
+
+func replay(log *slog.Logger, w http.ResponseWriter, r *http.Request, record models.IdempotencyRecord, hash string) {
+	if record.RequestHash != hash {
+		log.Info("idempotency key reused with a different payload")
+
+		problem.Respond(w, r, http.StatusUnprocessableEntity, problem.CodeIdempotencyMismatch, "idempotency key was used with a different request")
+
+		return
+	}
+
+	if record.Status == 0 {
+		log.Info("request with idempotency key is in progress")
+
+		problem.Respond(w, r, http.StatusConflict, problem.CodeIdempotencyInFlight, "request with this idempotency key is in progress")
+
+		return
+	}
+
+	log.Info("replaying stored response", slog.Int("status", record.Status))
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(record.Status)
+
+	if _, err := w.Write(record.Body); err != nil {
+		log.Error("failed to write stored response", sl.Err(err))
+	}
+}
+
+// requestHash fingerprints the parts of a request that must not change
+// between retries: method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,313 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/principal"
+)
+
+// fakeStorage keeps records in memory the way the database does: a key is
+// reserved once per client until it is released or expires.
+type fakeStorage struct {
+	mu         sync.Mutex
+	records    map[string]models.IdempotencyRecord
+	reserveErr error
+	completed  int
+	released   int
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{records: make(map[string]models.IdempotencyRecord)}
+}
+
+func (s *fakeStorage) ReserveIdempotencyKey(_ context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reserveErr != nil {
+		return models.IdempotencyRecord{}, false, s.reserveErr
+	}
+
+	if existing, ok := s.records[record.Client+"/"+record.Key]; ok {
+		return existing, false, nil
+	}
+
+	s.records[record.Client+"/"+record.Key] = record
+
+	return record, true, nil
+}
+
+func (s *fakeStorage) CompleteIdempotencyKey(_ context.Context, record models.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[record.Client+"/"+record.Key] = record
+	s.completed++
+
+	return nil
+}
+
+func (s *fakeStorage) ReleaseIdempotencyKey(_ context.Context, client, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, client+"/"+key)
+	s.released++
+
+	return nil
+}
+
+// countingHandler answers with status and counts the requests it served.
+type countingHandler struct {
+	status int
+	calls  int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+
+	body, _ := io.ReadAll(r.Body)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `,"body":"` + string(body) + `"}`))
+}
+
+func newRequest(subject, key, body string) *http.Request {
+	return newRequestTo("/songs", subject, key, body)
+}
+
+func newRequestTo(path, subject, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+
+	if subject != "" {
+		r = r.WithContext(principal.With(r.Context(), models.Principal{Subject: subject, Method: models.AuthMethodBearer}))
+	}
+
+	return r
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	return rec
+}
+
+func newMiddleware(storage RecordStorage, next http.Handler) http.Handler {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	return New(log, storage, time.Hour)(next)
+}
+
+func code(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var p problem.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+
+	return p.Code
+}
+
+func TestWithoutKey(t *testing.T) {
+	storage := newFakeStorage()
+	next := &countingHandler{status: http.StatusCreated}
+	h := newMiddleware(storage, next)
+
+	serve(h, newRequest("alice", "", `{}`))
+	serve(h, newRequest("alice", "", `{}`))
+
+	if next.calls != 2 || len(storage.records) != 0 {
+		t.Errorf("calls = %d, records = %d, want 2 calls and nothing stored", next.calls, len(storage.records))
+	}
+}
+
+func TestReplay(t *testing.T) {
+	storage := newFakeStorage()
+	next := &countingHandler{status: http.StatusCreated}
+	h := newMiddleware(storage, next)
+
+	first := serve(h, newRequest("alice", "key-1", `a`))
+	retry := serve(h, newRequest("alice", "key-1", `a`))
+
+	if next.calls != 1 {
+		t.Fatalf("handler calls = %d, want 1", next.calls)
+	}
+
+	if storage.completed != 1 || storage.released != 0 {
+		t.Errorf("completed, released = %d, %d, want 1, 0", storage.completed, storage.released)
+	}
+
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+
+	if got := retry.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("replayed Content-Type = %q, want application/json", got)
+	}
+
+	if first.Header().Get(replayedHeader) != "" || retry.Header().Get(replayedHeader) != "true" {
+		t.Errorf("%s = %q then %q, want only the replay marked", replayedHeader, first.Header().Get(replayedHeader), retry.Header().Get(replayedHeader))
+	}
+}
+
+func TestKeysArePerClient(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	h := newMiddleware(newFakeStorage(), next)
+
+	serve(h, newRequest("alice", "key-1", `a`))
+	rec := serve(h, newRequest("bob", "key-1", `a`))
+
+	if next.calls != 2 || rec.Header().Get(replayedHeader) != "" {
+		t.Errorf("calls = %d, want the key of another client not to be replayed", next.calls)
+	}
+}
+
+func TestReplayDifferentRequest(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	h := newMiddleware(newFakeStorage(), next)
+
+	serve(h, newRequest("alice", "key-1", `a`))
+
+	tests := []struct {
+		name string
+		r    *http.Request
+	}{
+		{"body", newRequest("alice", "key-1", `b`)},
+		{"path", newRequestTo("/songs/1/merge", "alice", "key-1", `a`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.r)
+
+			if rec.Code != http.StatusUnprocessableEntity || code(t, rec) != problem.CodeIdempotencyMismatch {
+				t.Errorf("response = %d, want 422 %s", rec.Code, problem.CodeIdempotencyMismatch)
+			}
+		})
+	}
+
+	if next.calls != 1 {
+		t.Errorf("handler calls = %d, want 1", next.calls)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	storage := newFakeStorage()
+	h := newMiddleware(storage, &countingHandler{status: http.StatusCreated})
+
+	storage.records["alice/key-1"] = models.IdempotencyRecord{
+		Client:      "alice",
+		Key:         "key-1",
+		RequestHash: requestHash(newRequest("alice", "key-1", `a`), []byte(`a`)),
+	}
+
+	rec := serve(h, newRequest("alice", "key-1", `a`))
+
+	if rec.Code != http.StatusConflict || code(t, rec) != problem.CodeIdempotencyInFlight {
+		t.Errorf("response = %d, want 409 %s", rec.Code, problem.CodeIdempotencyInFlight)
+	}
+}
+
+func TestReleasedResponses(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusUnauthorized, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			storage := newFakeStorage()
+			next := &countingHandler{status: status}
+			h := newMiddleware(storage, next)
+
+			serve(h, newRequest("alice", "key-1", `a`))
+			rec := serve(h, newRequest("alice", "key-1", `a`))
+
+			if next.calls != 2 || rec.Header().Get(replayedHeader) != "" {
+				t.Errorf("handler calls = %d, want the retry served for real", next.calls)
+			}
+
+			if storage.completed != 0 || storage.released != 2 {
+				t.Errorf("completed, released = %d, %d, want 0, 2", storage.completed, storage.released)
+			}
+		})
+	}
+}
+
+func TestStoredClientErrors(t *testing.T) {
+	storage := newFakeStorage()
+	next := &countingHandler{status: http.StatusNotFound}
+	h := newMiddleware(storage, next)
+
+	serve(h, newRequest("alice", "key-1", `a`))
+	rec := serve(h, newRequest("alice", "key-1", `a`))
+
+	if next.calls != 1 || rec.Code != http.StatusNotFound || rec.Header().Get(replayedHeader) != "true" {
+		t.Errorf("calls = %d, replay = %d, want the 404 replayed", next.calls, rec.Code)
+	}
+}
+
+func TestReleaseOnPanic(t *testing.T) {
+	storage := newFakeStorage()
+	h := newMiddleware(storage, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic of the handler was swallowed")
+			}
+		}()
+
+		serve(h, newRequest("alice", "key-1", `a`))
+	}()
+
+	if storage.released != 1 || len(storage.records) != 0 {
+		t.Errorf("released = %d, records = %d, want the key released", storage.released, len(storage.records))
+	}
+}
+
+func TestRejectedRequests(t *testing.T) {
+	t.Run("key too long", func(t *testing.T) {
+		rec := serve(newMiddleware(newFakeStorage(), &countingHandler{status: http.StatusCreated}), newRequest("alice", strings.Repeat("k", maxKeyLength+1), `a`))
+
+		if rec.Code != http.StatusBadRequest || code(t, rec) != problem.CodeIdempotencyKeyLength {
+			t.Errorf("response = %d, want 400 %s", rec.Code, problem.CodeIdempotencyKeyLength)
+		}
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		rec := serve(newMiddleware(newFakeStorage(), &countingHandler{status: http.StatusCreated}), newRequest("", "key-1", `a`))
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want 401", rec.Code)
+		}
+	})
+
+	t.Run("storage fails", func(t *testing.T) {
+		storage := newFakeStorage()
+		storage.reserveErr = errors.New("connection refused")
+		next := &countingHandler{status: http.StatusCreated}
+
+		rec := serve(newMiddleware(storage, next), newRequest("alice", "key-1", `a`))
+
+		if rec.Code != http.StatusInternalServerError || next.calls != 0 {
+			t.Errorf("status = %d, calls = %d, want 500 without the handler", rec.Code, next.calls)
+		}
+	})
+}
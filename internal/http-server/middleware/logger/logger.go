@@ -2,13 +2,19 @@ package logger
 
 import (
 	"log/slog"
+	"math/rand"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+
+	"effective_mobile/internal/lib/tracing"
 )
 
-func New(log *slog.Logger) func(next http.Handler) http.Handler {
+// New logs every completed request. Requests answered below 400 are logged
+// with probability successSampleRatio, so busy services can thin out the
+// lines nobody reads; errors are always logged.
+func New(log *slog.Logger, successSampleRatio float64) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		log := log.With(
 			slog.String("component", "middleware/logger"),
@@ -23,11 +29,16 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 				slog.String("remote_addr", r.RemoteAddr),
 				slog.String("user_agent", r.UserAgent()),
 				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
 			)
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
 			defer func() {
+				if ww.Status() < http.StatusBadRequest && successSampleRatio < 1 && rand.Float64() >= successSampleRatio {
+					return
+				}
+
 				entry.Info("request completed",
 					slog.Int("status", ww.Status()),
 					slog.Int("bytes", ww.BytesWritten()),
@@ -0,0 +1,186 @@
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// New validates requests, and optionally responses, against the operations
+// of the spec behind router. Invalid requests are rejected with a 400
+// listing every failed parameter and body field. Invalid responses are only
+// logged, as the client has already got them. Requests the spec does not
+// describe are passed through untouched.
+//
+// Authentication is left to the auth middleware, and request bodies are
+// validated as JSON whatever their Content-Type says, because that is how
+// the handlers decode them.
+func New(log *slog.Logger, router routers.Router, validateRequests, validateResponses bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/openapi"),
+		)
+
+		if !validateRequests && !validateResponses {
+			return next
+		}
+
+		log.Info("openapi validation middleware enabled",
+			slog.Bool("requests", validateRequests),
+			slog.Bool("responses", validateResponses),
+		)
+
+		options := &openapi3filter.Options{
+			MultiError:          true,
+			SkipSettingDefaults: true,
+			AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
+			)
+
+			input, err := requestInput(r, route, pathParams, options)
+			if err != nil {
+				log.Error("failed to read request body", sl.Err(err))
+
+				problem.Respond(w, r, http.StatusBadRequest, problem.CodeBadRequest, "failed to read request")
+
+				return
+			}
+
+			if validateRequests {
+				if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+					log.Info("request does not match the spec", sl.Err(err))
+
+					problem.Write(w, r, requestProblem(err))
+
+					return
+				}
+			}
+
+			if !validateResponses {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			var body bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			responseInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 status,
+				Header:                 ww.Header(),
+				Options:                options,
+			}
+			responseInput.SetBodyBytes(body.Bytes())
+
+			if err := openapi3filter.ValidateResponse(context.WithoutCancel(r.Context()), responseInput); err != nil {
+				log.Warn("response does not match the spec",
+					slog.String("route", route.Path),
+					slog.Int("status", status),
+					sl.Err(err),
+				)
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// requestInput builds the validation input from a copy of r, leaving the
+// body of r readable for the handlers.
+func requestInput(r *http.Request, route *routers.Route, pathParams map[string]string, options *openapi3filter.Options) (*openapi3filter.RequestValidationInput, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	vr := r.Clone(r.Context())
+	vr.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) > 0 {
+		vr.Header.Set("Content-Type", "application/json")
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    vr,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    options,
+	}, nil
+}
+
+func requestProblem(err error) problem.Problem {
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "request does not match the API spec")
+	p.Errors = fieldErrors(err, "")
+
+	return p
+}
+
+// fieldErrors flattens the errors of ValidateRequest into one entry per
+// failed parameter or body field.
+func fieldErrors(err error, field string) []problem.FieldError {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var out []problem.FieldError
+		for _, nested := range e {
+			out = append(out, fieldErrors(nested, field)...)
+		}
+
+		return out
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			field = e.Parameter.Name
+		case e.RequestBody != nil:
+			field = "body"
+		}
+
+		switch e.Err.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return fieldErrors(e.Err, field)
+		}
+
+		return []problem.FieldError{{Field: field, Code: "invalid", Message: e.Error()}}
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 && field == "body" {
+			field = strings.Join(pointer, ".")
+		}
+
+		return []problem.FieldError{{Field: field, Code: e.SchemaField, Message: e.Reason}}
+	default:
+		return []problem.FieldError{{Field: field, Code: "invalid", Message: err.Error()}}
+	}
+}
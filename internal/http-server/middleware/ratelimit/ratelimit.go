@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/logger/sl"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/ratelimit"
+	"effective_mobile/internal/lib/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	ClassRead   = "read"
+	ClassWrite  = "write"
+	ClassExport = "export"
+)
+
+type Limiter interface {
+	Allow(key string) ratelimit.Result
+}
+
+type QuotaCounter interface {
+	IncrementQuota(ctx context.Context, client, class string, day time.Time) (int, error)
+}
+
+// New limits requests of class per client with limiter and, when dailyQuota
+// is positive, to dailyQuota requests per UTC day counted in quotaCounter.
+// Clients are told apart by their principal, falling back to the remote IP
+// for anonymous requests, so it must run after the auth middleware.
+func New(
+	log *slog.Logger,
+	class string,
+	limiter Limiter,
+	quotaCounter QuotaCounter,
+	dailyQuota int,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/ratelimit"),
+			slog.String("class", class),
+		)
+
+		log.Info("rate limit middleware enabled")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("trace_id", tracing.TraceID(r.Context())),
+			)
+
+			client := clientKey(r)
+
+			res := limiter.Allow(client)
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", seconds(res.Reset))
+
+			if !res.Allowed {
+				log.Info("rate limit exceeded", slog.String("client", client))
+
+				if res.RetryAfter > 0 {
+					w.Header().Set("Retry-After", seconds(res.RetryAfter))
+				}
+
+				problem.Respond(w, r, http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded")
+
+				return
+			}
+
+			if dailyQuota > 0 {
+				now := time.Now().UTC()
+
+				count, err := quotaCounter.IncrementQuota(r.Context(), client, class, now)
+				if err != nil {
+					log.Error("failed to count quota", sl.Err(err))
+
+					problem.Respond(w, r, http.StatusInternalServerError, problem.CodeInternal, "")
+
+					return
+				}
+
+				w.Header().Set("X-Quota-Limit", strconv.Itoa(dailyQuota))
+				w.Header().Set("X-Quota-Remaining", strconv.Itoa(max(dailyQuota-count, 0)))
+
+				if count > dailyQuota {
+					log.Info("daily quota exceeded", slog.String("client", client))
+
+					midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+					w.Header().Set("Retry-After", seconds(midnight.Sub(now)))
+
+					problem.Respond(w, r, http.StatusTooManyRequests, problem.CodeQuotaExceeded, "daily quota exceeded")
+
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func clientKey(r *http.Request) string {
+	if p, ok := principal.FromContext(r.Context()); ok && p.Method != models.AuthMethodAnonymous {
+		return p.Subject
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,224 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/api/problem"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/ratelimit"
+)
+
+type fakeLimiter struct {
+	result ratelimit.Result
+	keys   []string
+}
+
+func (l *fakeLimiter) Allow(key string) ratelimit.Result {
+	l.keys = append(l.keys, key)
+
+	return l.result
+}
+
+type fakeQuota struct {
+	count int
+	err   error
+	class string
+}
+
+func (q *fakeQuota) IncrementQuota(_ context.Context, _, class string, _ time.Time) (int, error) {
+	q.class = class
+
+	return q.count, q.err
+}
+
+func serve(t *testing.T, limiter Limiter, quota QuotaCounter, dailyQuota int, r *http.Request) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	New(log, ClassRead, limiter, quota, dailyQuota)(next).ServeHTTP(rec, r)
+
+	return rec, called
+}
+
+func code(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var p problem.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+
+	return p.Code
+}
+
+func TestAllowed(t *testing.T) {
+	limiter := &fakeLimiter{result: ratelimit.Result{Allowed: true, Limit: 40, Remaining: 39, Reset: 1500 * time.Millisecond}}
+
+	rec, called := serve(t, limiter, nil, 0, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+	if !called {
+		t.Fatal("allowed request did not reach the handler")
+	}
+
+	want := map[string]string{
+		"RateLimit-Limit":     "40",
+		"RateLimit-Remaining": "39",
+		"RateLimit-Reset":     "2",
+		"Retry-After":         "",
+		"X-Quota-Limit":       "",
+	}
+
+	for header, value := range want {
+		if got := rec.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	limiter := &fakeLimiter{result: ratelimit.Result{Limit: 5, Reset: 2 * time.Second, RetryAfter: 300 * time.Millisecond}}
+	quota := &fakeQuota{}
+
+	rec, called := serve(t, limiter, quota, 100, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+	if called {
+		t.Fatal("limited request reached the handler")
+	}
+
+	if rec.Code != http.StatusTooManyRequests || code(t, rec) != problem.CodeRateLimited {
+		t.Errorf("response = %d, want 429 %s", rec.Code, problem.CodeRateLimited)
+	}
+
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+
+	if got := rec.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	if quota.class != "" {
+		t.Error("limited request was counted against the daily quota")
+	}
+}
+
+func TestRateLimitedWithoutRetryTime(t *testing.T) {
+	limiter := &fakeLimiter{result: ratelimit.Result{Limit: 1}}
+
+	rec, _ := serve(t, limiter, nil, 0, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+
+	if _, ok := rec.Header()["Retry-After"]; ok {
+		t.Error("Retry-After is sent without a known retry time")
+	}
+}
+
+func TestDailyQuota(t *testing.T) {
+	limiter := &fakeLimiter{result: ratelimit.Result{Allowed: true, Limit: 40, Remaining: 39}}
+
+	t.Run("within quota", func(t *testing.T) {
+		quota := &fakeQuota{count: 3}
+
+		rec, called := serve(t, limiter, quota, 10, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+		if !called {
+			t.Fatal("request within the quota did not reach the handler")
+		}
+
+		if quota.class != ClassRead {
+			t.Errorf("quota class = %q, want %q", quota.class, ClassRead)
+		}
+
+		if got := rec.Header().Get("X-Quota-Limit"); got != "10" {
+			t.Errorf("X-Quota-Limit = %q, want 10", got)
+		}
+
+		if got := rec.Header().Get("X-Quota-Remaining"); got != "7" {
+			t.Errorf("X-Quota-Remaining = %q, want 7", got)
+		}
+	})
+
+	t.Run("quota exceeded", func(t *testing.T) {
+		quota := &fakeQuota{count: 11}
+
+		rec, called := serve(t, limiter, quota, 10, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+		if called {
+			t.Fatal("request over the quota reached the handler")
+		}
+
+		if rec.Code != http.StatusTooManyRequests || code(t, rec) != problem.CodeQuotaExceeded {
+			t.Errorf("response = %d, want 429 %s", rec.Code, problem.CodeQuotaExceeded)
+		}
+
+		if got := rec.Header().Get("X-Quota-Remaining"); got != "0" {
+			t.Errorf("X-Quota-Remaining = %q, want 0", got)
+		}
+
+		// The quota resets at the next UTC midnight.
+		retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retryAfter <= 0 || retryAfter > 24*60*60 {
+			t.Errorf("Retry-After = %q, want seconds until midnight", rec.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("counter fails", func(t *testing.T) {
+		quota := &fakeQuota{err: errors.New("connection refused")}
+
+		rec, called := serve(t, limiter, quota, 10, httptest.NewRequest(http.MethodGet, "/songs", nil))
+
+		if called || rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, handler called = %t, want 500 without the handler", rec.Code, called)
+		}
+	})
+}
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		want      string
+	}{
+		{"authenticated", &models.Principal{Subject: "alice", Method: models.AuthMethodBearer}, "alice"},
+		{"anonymous", &models.Principal{Subject: "anonymous", Method: models.AuthMethodAnonymous}, "ip:192.0.2.1"},
+		{"no principal", nil, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{result: ratelimit.Result{Allowed: true}}
+
+			r := httptest.NewRequest(http.MethodGet, "/songs", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			if tt.principal != nil {
+				r = r.WithContext(principal.With(r.Context(), *tt.principal))
+			}
+
+			serve(t, limiter, nil, 0, r)
+
+			if len(limiter.keys) != 1 || limiter.keys[0] != tt.want {
+				t.Errorf("client keys = %v, want %q", limiter.keys, tt.want)
+			}
+		})
+	}
+}
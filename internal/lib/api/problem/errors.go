@@ -0,0 +1,127 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"effective_mobile/internal/clients"
+	"effective_mobile/internal/service"
+	"effective_mobile/internal/storage"
+)
+
+// Stable error codes. Clients depend on them, so existing codes must not be
+// renamed.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeRouteNotFound        = "route_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeInvalidJSON          = "invalid_json"
+	CodeEmptyBody            = "empty_body"
+	CodeValidationFailed     = "validation_failed"
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeInvalidVerse         = "invalid_verse"
+	CodeInvalidSection       = "invalid_section"
+	CodeInvalidLRC           = "invalid_lrc"
+	CodeInvalidOffset        = "invalid_offset"
+	CodeLyricsNotSynced      = "lyrics_not_synced"
+	CodeInvalidLanguage      = "invalid_language"
+	CodeTranslationNotFound  = "translation_not_found"
+	CodeOriginalConflict     = "original_lyrics_conflict"
+	CodeInvalidChordPro      = "invalid_chordpro"
+	CodeInvalidTranspose     = "invalid_transpose"
+	CodeInvalidCapo          = "invalid_capo"
+	CodeChordsNotFound       = "chords_not_found"
+	CodeInvalidTop           = "invalid_top"
+	CodeInvalidThreshold     = "invalid_threshold"
+	CodeInvalidLimit         = "invalid_limit"
+	CodeMergeSelf            = "merge_into_self"
+	CodeInvalidSuggestType   = "invalid_suggest_type"
+	CodeInvalidDate          = "invalid_date"
+	CodeEmptyUpdate          = "empty_update"
+	CodeInvalidScope         = "invalid_scope"
+	CodeInvalidIP            = "invalid_ip"
+	CodeSongNotFound         = "song_not_found"
+	CodeSongExists           = "song_exists"
+	CodeAPIKeyNotFound       = "api_key_not_found"
+	CodeUnauthorized         = "unauthorized"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeInvalidToken         = "invalid_token"
+	CodeForbidden            = "forbidden"
+	CodeRateLimited          = "rate_limited"
+	CodeQuotaExceeded        = "quota_exceeded"
+	CodeIdempotencyKeyLength = "idempotency_key_too_long"
+	CodeIdempotencyInFlight  = "idempotency_in_progress"
+	CodeIdempotencyMismatch  = "idempotency_key_reused"
+	CodeExternalRejected     = "external_api_rejected"
+	CodeExternalFailed       = "external_api_failed"
+	CodeTimeout              = "timeout"
+	CodeIndexNotReady        = "similar_index_not_ready"
+	CodeInternal             = "internal_error"
+)
+
+type mapping struct {
+	err    error
+	status int
+	code   string
+	detail string
+}
+
+// mappings is the single place where domain errors get their HTTP status
+// and code. The first matching entry wins.
+var mappings = []mapping{
+	{storage.ErrSongNotFound, http.StatusNotFound, CodeSongNotFound, "song not found"},
+	{storage.ErrSongExists, http.StatusConflict, CodeSongExists, "song already exists"},
+	{storage.ErrKeyNotFound, http.StatusNotFound, CodeAPIKeyNotFound, "api key not found"},
+	{storage.ErrTranslationNotFound, http.StatusNotFound, CodeTranslationNotFound, "song has no lyrics in this language"},
+	{storage.ErrChordsNotFound, http.StatusNotFound, CodeChordsNotFound, "song has no chord sheet"},
+	{storage.ErrOriginalConflict, http.StatusConflict, CodeOriginalConflict, "song already has original lyrics in another language, or this language is the original"},
+	{storage.ErrTokenNotFound, http.StatusUnauthorized, CodeInvalidToken, "invalid token"},
+	{service.ErrForbidden, http.StatusForbidden, CodeForbidden, "forbidden"},
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, CodeInvalidCredentials, "invalid credentials"},
+	{service.ErrInvalidToken, http.StatusUnauthorized, CodeInvalidToken, "invalid token"},
+	{service.ErrInvalidAPIKey, http.StatusUnauthorized, CodeUnauthorized, "invalid api key"},
+	{service.ErrInvalidVerseNumber, http.StatusBadRequest, CodeInvalidVerse, "invalid verse number"},
+	{service.ErrInvalidSection, http.StatusBadRequest, CodeInvalidSection, "unknown section type"},
+	{service.ErrInvalidLRC, http.StatusBadRequest, CodeInvalidLRC, "lyrics are not valid LRC"},
+	{service.ErrInvalidOffset, http.StatusBadRequest, CodeInvalidOffset, "playback offset must not be negative"},
+	{service.ErrLyricsNotSynced, http.StatusNotFound, CodeLyricsNotSynced, "song has no synced lyrics"},
+	{service.ErrInvalidLanguage, http.StatusBadRequest, CodeInvalidLanguage, "language must be a BCP-47 tag such as en or pt-BR"},
+	{service.ErrInvalidChordPro, http.StatusBadRequest, CodeInvalidChordPro, "chord sheet is not valid ChordPro"},
+	{service.ErrInvalidTranspose, http.StatusBadRequest, CodeInvalidTranspose, "transpose must be between -12 and 12 semitones"},
+	{service.ErrInvalidCapo, http.StatusBadRequest, CodeInvalidCapo, "capo must be between 0 and 12"},
+	{service.ErrInvalidTop, http.StatusBadRequest, CodeInvalidTop, "top must be between 1 and 100 words"},
+	{service.ErrInvalidThreshold, http.StatusBadRequest, CodeInvalidThreshold, "threshold must be greater than 0 and at most 1"},
+	{service.ErrInvalidLimit, http.StatusBadRequest, CodeInvalidLimit, "limit is out of the allowed range"},
+	{service.ErrMergeSelf, http.StatusBadRequest, CodeMergeSelf, "a song cannot be merged into itself"},
+	{service.ErrInvalidSuggestType, http.StatusBadRequest, CodeInvalidSuggestType, "type must be group, song or all"},
+	{service.ErrInvalidDateFormat, http.StatusBadRequest, CodeInvalidDate, "invalid date format, expected DD.MM.YYYY"},
+	{service.ErrEmptyUpdate, http.StatusBadRequest, CodeEmptyUpdate, "update request has no fields"},
+	{service.ErrInvalidScope, http.StatusBadRequest, CodeInvalidScope, "unknown api key scope"},
+	{service.ErrInvalidIP, http.StatusBadRequest, CodeInvalidIP, "invalid ip address or network"},
+	{clients.ErrBadRequest, http.StatusUnprocessableEntity, CodeExternalRejected, "external API rejected the song"},
+	{clients.ErrInternal, http.StatusBadGateway, CodeExternalFailed, "external API failed"},
+	{service.ErrIndexNotReady, http.StatusServiceUnavailable, CodeIndexNotReady, "similar songs are not available yet, try again later"},
+	{context.DeadlineExceeded, http.StatusServiceUnavailable, CodeTimeout, "request took too long, try again"},
+}
+
+// FromError maps err to a problem. The detail of a service.DetailedError
+// is added to the detail of its code. Unknown errors become a 500 without
+// detail, so internals are not leaked to clients.
+func FromError(err error) Problem {
+	for _, m := range mappings {
+		if errors.Is(err, m.err) {
+			detail := m.detail
+
+			var detailed *service.DetailedError
+			if errors.As(err, &detailed) && errors.Is(detailed.Err, m.err) {
+				detail += ": " + detailed.Detail
+			}
+
+			return New(m.status, m.code, detail)
+		}
+	}
+
+	return New(http.StatusInternalServerError, CodeInternal, "")
+}
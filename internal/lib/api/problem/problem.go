@@ -0,0 +1,122 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+)
+
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 error response. Code is a stable machine-readable
+// identifier clients can switch on; Detail is for humans and may change.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Code     string       `json:"code"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a request field that failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func New(status int, code, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// LogLevel is the level to log the failure at: server errors are errors,
+// anything the client caused is informational.
+func (p Problem) LogLevel() slog.Level {
+	if p.Status >= http.StatusInternalServerError {
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
+
+// Write sends p with the request ID as its instance.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Instance == "" {
+		p.Instance = middleware.GetReqID(r.Context())
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Respond writes a problem with status, code and detail.
+func Respond(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
+
+// Error writes the problem err maps to and returns it.
+func Error(w http.ResponseWriter, r *http.Request, err error) Problem {
+	p := FromError(err)
+
+	Write(w, r, p)
+
+	return p
+}
+
+// NotFound answers requests that match no route.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Respond(w, r, http.StatusNotFound, CodeRouteNotFound, "no route for "+r.URL.Path)
+}
+
+// MethodNotAllowed answers requests to a known route with a wrong method.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Respond(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed for "+r.URL.Path)
+}
+
+// Validation writes a 400 listing every field that failed validation in err.
+func Validation(w http.ResponseWriter, r *http.Request, err error) {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		Respond(w, r, http.StatusInternalServerError, CodeInternal, "")
+
+		return
+	}
+
+	p := New(http.StatusBadRequest, CodeValidationFailed, "request validation failed")
+
+	for _, err := range errs {
+		p.Errors = append(p.Errors, FieldError{
+			Field:   err.Field(),
+			Code:    err.Tag(),
+			Message: fieldMessage(err),
+		})
+	}
+
+	Write(w, r, p)
+}
+
+func fieldMessage(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required", "required_unless":
+		return fmt.Sprintf("field %s is a required field", err.Field())
+	case "min":
+		return fmt.Sprintf("field %s must have at least %s elements", err.Field(), err.Param())
+	default:
+		return fmt.Sprintf("field %s is not valid", err.Field())
+	}
+}
@@ -1,14 +1,5 @@
 package response
 
-import (
-	"fmt"
-	"net/http"
-	"strings"
-
-	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
-)
-
 type Response struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
@@ -24,24 +15,3 @@ func OK() Response {
 		Status: StatusOK,
 	}
 }
-
-func Error(w http.ResponseWriter, r *http.Request, statusCode int, message string) {
-	render.Status(r, statusCode)
-	render.JSON(w, r, Response{
-		Status: StatusError,
-		Error:  message,
-	})
-}
-
-func ValidationErrors(errs validator.ValidationErrors) string {
-	var errMsgs []string
-	for _, err := range errs {
-		switch err.ActualTag() {
-		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
-		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
-		}
-	}
-	return strings.Join(errMsgs, ", ")
-}
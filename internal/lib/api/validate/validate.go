@@ -0,0 +1,31 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var v = newValidator()
+
+// Struct validates s by its validate tags. Failed fields are reported by
+// their JSON name, as clients know them.
+func Struct(s interface{}) error {
+	return v.Struct(s)
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return f.Name
+		}
+
+		return name
+	})
+
+	return v
+}
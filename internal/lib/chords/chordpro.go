@@ -0,0 +1,269 @@
+// Package chords parses chord sheets in ChordPro format, transposes them and
+// renders them as text.
+package chords
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/lyrics"
+)
+
+var ErrInvalidChordPro = errors.New("invalid chordpro")
+
+// MaxCapo is the highest capo position accepted.
+const MaxCapo = 12
+
+// sectionKinds maps the part of start_of_* directives to the marker the
+// lyrics parser knows the section by.
+var sectionKinds = map[string]string{
+	"chorus": "Chorus",
+	"c":      "Chorus",
+	"verse":  "Verse",
+	"v":      "Verse",
+	"bridge": "Bridge",
+	"b":      "Bridge",
+}
+
+type parser struct {
+	sheet models.ChordSheet
+	// text holds a line of plain lyrics for every source line, so that the
+	// lyrics parser can split them into sections; lines holds the chords.
+	text  []string
+	lines []models.ChordLine
+	// repeated is set after a {chorus} directive, until a new section starts.
+	repeated bool
+	skipping string
+}
+
+// Parse parses a ChordPro chord sheet.
+//
+// Lyrics lines carry chords in brackets before the syllable they are played
+// on: "[G]Ooh baby, [Em]don't you know". Sections are marked with
+// {start_of_verse}, {start_of_chorus}, {start_of_bridge} and their short
+// forms, or left to be found by repeats as in plain lyrics, and {chorus}
+// repeats the last chorus. Title, artist, key and capo are read from their
+// directives; tabs, grids, comments and unknown directives are skipped.
+func Parse(src string) (models.ChordSheet, error) {
+	p := &parser{}
+
+	for n, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if err := p.line(strings.TrimRight(raw, " \t")); err != nil {
+			return models.ChordSheet{}, fmt.Errorf("%w: line %d: %s", ErrInvalidChordPro, n+1, err)
+		}
+	}
+
+	sections, spans := lyrics.Split(strings.Join(p.text, "\n"))
+	if len(sections) == 0 {
+		return models.ChordSheet{}, fmt.Errorf("%w: no lyrics", ErrInvalidChordPro)
+	}
+
+	p.sheet.Sections = make([]models.ChordSection, 0, len(sections))
+
+	for i, section := range sections {
+		chordSection := models.ChordSection{
+			Type:   section.Type,
+			Number: section.Number,
+			Label:  section.Label,
+			Lines:  make([]models.ChordLine, 0, len(spans[i])),
+		}
+
+		// Markers made from directives without a label carry no information.
+		if isBareKind(section.Label) {
+			chordSection.Label = ""
+		}
+
+		for _, n := range spans[i] {
+			chordSection.Lines = append(chordSection.Lines, p.lines[n])
+		}
+
+		p.sheet.Sections = append(p.sheet.Sections, chordSection)
+	}
+
+	return p.sheet, nil
+}
+
+func (p *parser) line(line string) error {
+	trimmed := strings.TrimSpace(line)
+
+	if p.skipping != "" {
+		if isDirective(trimmed) {
+			name, _ := directive(trimmed)
+			if name == "end_of_"+p.skipping || name == "eo"+p.skipping[:1] {
+				p.skipping = ""
+			}
+		}
+
+		return nil
+	}
+
+	switch {
+	case strings.HasPrefix(trimmed, "#"):
+		return nil
+	case isDirective(trimmed):
+		return p.directive(trimmed)
+	case strings.HasPrefix(trimmed, "{"):
+		return errors.New("unclosed directive")
+	}
+
+	chordLine, err := parseChordLine(line)
+	if err != nil {
+		return err
+	}
+
+	text := chordLine.Lyrics
+	if strings.TrimSpace(text) == "" && len(chordLine.Chords) > 0 {
+		// A line of chords only must not split the section it is in.
+		names := make([]string, 0, len(chordLine.Chords))
+		for _, c := range chordLine.Chords {
+			names = append(names, c.Chord)
+		}
+		text = strings.Join(names, " ")
+	}
+
+	if p.repeated && strings.TrimSpace(text) != "" {
+		// Without this the lines would become the repeated chorus.
+		p.emit("[Verse]", models.ChordLine{})
+		p.repeated = false
+	}
+
+	p.emit(text, chordLine)
+
+	return nil
+}
+
+func (p *parser) directive(line string) error {
+	name, value := directive(line)
+
+	switch name {
+	case "title", "t":
+		p.sheet.Title = value
+	case "artist", "subtitle", "st":
+		if p.sheet.Artist == "" {
+			p.sheet.Artist = value
+		}
+	case "key":
+		if !IsChord(value) {
+			return fmt.Errorf("invalid key %q", value)
+		}
+		p.sheet.Key = value
+	case "capo":
+		capo, err := strconv.Atoi(value)
+		if err != nil || capo < 0 || capo > MaxCapo {
+			return fmt.Errorf("invalid capo %q", value)
+		}
+		p.sheet.Capo = capo
+	case "chorus":
+		p.emit("", models.ChordLine{})
+		p.emit("[Chorus]", models.ChordLine{})
+		p.repeated = true
+	case "start_of_tab", "sot":
+		p.skipping = "tab"
+	case "start_of_grid", "sog":
+		p.skipping = "grid"
+	default:
+		switch {
+		case strings.HasPrefix(name, "start_of_"):
+			p.startSection(strings.TrimPrefix(name, "start_of_"), value)
+		case len(name) == 3 && strings.HasPrefix(name, "so") && sectionKinds[name[2:]] != "":
+			p.startSection(name[2:], value)
+		case strings.HasPrefix(name, "end_of_"), len(name) == 3 && strings.HasPrefix(name, "eo"):
+			p.emit("", models.ChordLine{})
+		}
+	}
+
+	return nil
+}
+
+// startSection emits the marker the lyrics parser types the section by. A
+// label that names another kind of section keeps the kind in front.
+func (p *parser) startSection(kind, label string) {
+	marker, ok := sectionKinds[kind]
+	if !ok {
+		marker = kind
+	}
+
+	if label != "" {
+		if lyrics.MarkerSection(label) == lyrics.MarkerSection(marker) {
+			marker = label
+		} else {
+			marker = marker + ": " + label
+		}
+	}
+
+	p.emit("", models.ChordLine{})
+	p.emit("["+marker+"]", models.ChordLine{})
+	p.repeated = false
+}
+
+func (p *parser) emit(text string, line models.ChordLine) {
+	p.text = append(p.text, text)
+	p.lines = append(p.lines, line)
+}
+
+func isBareKind(label string) bool {
+	for _, kind := range sectionKinds {
+		if label == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDirective(line string) bool {
+	return strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}")
+}
+
+// directive splits "{name: value}" or "{name value}" into its parts.
+func directive(line string) (name, value string) {
+	inner := strings.TrimSpace(line[1 : len(line)-1])
+
+	name, value, found := strings.Cut(inner, ":")
+	if !found {
+		name, value, _ = strings.Cut(inner, " ")
+	}
+
+	return strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+}
+
+func parseChordLine(line string) (models.ChordLine, error) {
+	var chordLine models.ChordLine
+	var lyricsText strings.Builder
+
+	for {
+		open := strings.IndexByte(line, '[')
+		if open < 0 {
+			lyricsText.WriteString(line)
+
+			break
+		}
+
+		closing := strings.IndexByte(line[open:], ']')
+		if closing < 0 {
+			return models.ChordLine{}, errors.New("unclosed chord bracket")
+		}
+
+		lyricsText.WriteString(line[:open])
+
+		chord := strings.TrimSpace(line[open+1 : open+closing])
+		if !IsChord(chord) {
+			return models.ChordLine{}, fmt.Errorf("invalid chord %q", chord)
+		}
+
+		chordLine.Chords = append(chordLine.Chords, models.ChordPosition{
+			Chord:    chord,
+			Position: utf8.RuneCountInString(lyricsText.String()),
+		})
+
+		line = line[open+closing+1:]
+	}
+
+	chordLine.Lyrics = lyricsText.String()
+
+	return chordLine, nil
+}
@@ -0,0 +1,218 @@
+package chords
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/lyrics"
+)
+
+func TestParse(t *testing.T) {
+	src := "{title: Song}\n{artist: Band}\n{key: G}\n{capo: 2}\n" +
+		"{start_of_verse}\n[G]Hello [Em]world\n{end_of_verse}\n" +
+		"{start_of_chorus: Refrain}\n[C]La la [D]la\n{end_of_chorus}\n" +
+		"# a comment\n{start_of_tab}\ne|---3---|\n{end_of_tab}\n" +
+		"[G]Another verse\n{chorus}"
+
+	got, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	verse := []models.ChordLine{{Lyrics: "Hello world", Chords: []models.ChordPosition{{Chord: "G", Position: 0}, {Chord: "Em", Position: 6}}}}
+	chorus := []models.ChordLine{{Lyrics: "La la la", Chords: []models.ChordPosition{{Chord: "C", Position: 0}, {Chord: "D", Position: 6}}}}
+	another := []models.ChordLine{{Lyrics: "Another verse", Chords: []models.ChordPosition{{Chord: "G", Position: 0}}}}
+
+	want := models.ChordSheet{
+		Title:  "Song",
+		Artist: "Band",
+		Key:    "G",
+		Capo:   2,
+		Sections: []models.ChordSection{
+			{Type: lyrics.SectionVerse, Number: 1, Lines: verse},
+			{Type: lyrics.SectionChorus, Number: 1, Label: "Refrain", Lines: chorus},
+			{Type: lyrics.SectionVerse, Number: 2, Lines: another},
+			{Type: lyrics.SectionChorus, Number: 2, Lines: chorus},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseChordsOnlyLine(t *testing.T) {
+	got, err := Parse("[Am] [F]\nWords [C]here")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if len(got.Sections) != 1 || len(got.Sections[0].Lines) != 2 {
+		t.Fatalf("Parse() = %+v, want one section of two lines", got)
+	}
+
+	if line := got.Sections[0].Lines[0]; line.Lyrics != " " || len(line.Chords) != 2 {
+		t.Errorf("chords only line = %+v", line)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"invalid chord", "Hello\n[H]world", "invalid chordpro: line 2: invalid chord \"H\""},
+		{"unclosed chord", "[G Hello", "invalid chordpro: line 1: unclosed chord bracket"},
+		{"unclosed directive", "{title: Song", "invalid chordpro: line 1: unclosed directive"},
+		{"invalid key", "{key: X}\n[G]Hello", "invalid chordpro: line 1: invalid key \"X\""},
+		{"capo too high", "{capo: 13}\n[G]Hello", "invalid chordpro: line 1: invalid capo \"13\""},
+		{"no lyrics", "{title: Song}", "invalid chordpro: no lyrics"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.src)
+			if !errors.Is(err, ErrInvalidChordPro) {
+				t.Fatalf("Parse() error = %v, want ErrInvalidChordPro", err)
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("Parse() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		chord     string
+		semitones int
+		want      string
+	}{
+		{"C", 2, "D"},
+		{"Am", 3, "Cm"},
+		{"F#m7", 1, "Gm7"},
+		{"E", 1, "F"},
+		{"G", 1, "G#"},
+		{"Bb", 1, "B"},
+		{"Eb", 1, "E"},
+		{"Db", 1, "D"},
+		{"Bb", -1, "A"},
+		{"Ab", 2, "Bb"},
+		{"B", 1, "C"},
+		{"C", -1, "B"},
+		{"A", 26, "B"},
+		{"G/B", -1, "F#/A#"},
+		{"F#m7/C#", 1, "Gm7/D"},
+		{"G/Bb", 1, "Ab/B"},
+		{"Dsus4", 12, "Dsus4"},
+		{"N.C.", 3, "N.C."},
+	}
+
+	for _, tt := range tests {
+		if got := Transpose(tt.chord, tt.semitones); got != tt.want {
+			t.Errorf("Transpose(%q, %d) = %q, want %q", tt.chord, tt.semitones, got, tt.want)
+		}
+	}
+}
+
+func TestIsChord(t *testing.T) {
+	for _, chord := range []string{"C", "F#m7", "Bbmaj7", "Dsus4", "G/B", "C#m7b5/G#", "N.C.", "x"} {
+		if !IsChord(chord) {
+			t.Errorf("IsChord(%q) = false, want true", chord)
+		}
+	}
+
+	for _, chord := range []string{"", "H", "c", "Hello", "G/H", "Chorus 2"} {
+		if IsChord(chord) {
+			t.Errorf("IsChord(%q) = true, want false", chord)
+		}
+	}
+}
+
+func TestShift(t *testing.T) {
+	sheet := models.ChordSheet{
+		Key:  "G",
+		Capo: 2,
+		Sections: []models.ChordSection{{
+			Type:   lyrics.SectionVerse,
+			Number: 1,
+			Lines: []models.ChordLine{
+				{Lyrics: "Hello", Chords: []models.ChordPosition{{Chord: "G", Position: 0}, {Chord: "D/F#", Position: 3}}},
+				{Lyrics: ""},
+			},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		transpose int
+		capo      int
+		key       string
+		chords    []string
+	}{
+		{"unchanged", 0, 2, "G", []string{"G", "D/F#"}},
+		{"capo removed keeps the sound", 0, 0, "G", []string{"A", "E/G#"}},
+		{"transposed with the same capo", 2, 2, "A", []string{"A", "E/G#"}},
+		{"transposed and capo moved up", 2, 4, "A", []string{"G", "D/F#"}},
+		{"transposed down a fifth", -7, 2, "C", []string{"C", "G/B"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Shift(sheet, tt.transpose, tt.capo)
+
+			if got.Key != tt.key || got.Capo != tt.capo || got.Transpose != tt.transpose {
+				t.Errorf("Shift() key, capo, transpose = %q, %d, %d, want %q, %d, %d",
+					got.Key, got.Capo, got.Transpose, tt.key, tt.capo, tt.transpose)
+			}
+
+			var chords []string
+			for _, c := range got.Sections[0].Lines[0].Chords {
+				chords = append(chords, c.Chord)
+			}
+
+			if !reflect.DeepEqual(chords, tt.chords) {
+				t.Errorf("Shift() chords = %v, want %v", chords, tt.chords)
+			}
+		})
+	}
+
+	if sheet.Sections[0].Lines[0].Chords[0].Chord != "G" {
+		t.Errorf("Shift() changed the chords of the sheet it was given")
+	}
+}
+
+func TestText(t *testing.T) {
+	sheet := models.ChordSheet{
+		Title:  "Song",
+		Artist: "Band",
+		Key:    "G",
+		Capo:   2,
+		Sections: []models.ChordSection{
+			{Type: lyrics.SectionVerse, Number: 1, Lines: []models.ChordLine{
+				{Lyrics: "Hello world", Chords: []models.ChordPosition{{Chord: "G", Position: 0}, {Chord: "Em", Position: 6}}},
+				{Lyrics: "", Chords: []models.ChordPosition{{Chord: "Cmaj7", Position: 0}, {Chord: "D", Position: 2}}},
+				{Lyrics: ""},
+			}},
+			{Type: lyrics.SectionChorus, Number: 1, Lines: []models.ChordLine{{Lyrics: "La la"}}},
+			{Type: lyrics.SectionBridge, Number: 1, Label: "Bridge: Guest", Lines: []models.ChordLine{{Lyrics: "Over"}}},
+		},
+	}
+
+	want := "Song - Band\nKey: G  Capo: 2\n\n" +
+		"[Verse 1]\nG     Em\nHello world\nCmaj7 D\n\n" +
+		"\n[Chorus]\nLa la\n" +
+		"\n[Bridge: Guest]\nOver\n"
+
+	if got := Text(sheet); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	if got, want := Text(models.ChordSheet{Sections: sheet.Sections[1:2]}), "[Chorus]\nLa la\n"; got != want {
+		t.Errorf("Text() without a header = %q, want %q", got, want)
+	}
+}
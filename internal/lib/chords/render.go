@@ -0,0 +1,88 @@
+package chords
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/lyrics"
+)
+
+// Text renders sheet as plain text with every chord above the character it
+// is played on, for a monospace font.
+func Text(sheet models.ChordSheet) string {
+	var b strings.Builder
+
+	if sheet.Title != "" {
+		b.WriteString(sheet.Title)
+		if sheet.Artist != "" {
+			b.WriteString(" - " + sheet.Artist)
+		}
+		b.WriteString("\n")
+	}
+
+	var info []string
+	if sheet.Key != "" {
+		info = append(info, "Key: "+sheet.Key)
+	}
+	if sheet.Capo > 0 {
+		info = append(info, fmt.Sprintf("Capo: %d", sheet.Capo))
+	}
+	if len(info) > 0 {
+		b.WriteString(strings.Join(info, "  ") + "\n")
+	}
+
+	for i, section := range sheet.Sections {
+		if i > 0 || b.Len() > 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString("[" + sectionTitle(section) + "]\n")
+
+		for _, line := range section.Lines {
+			if len(line.Chords) > 0 {
+				b.WriteString(chordLine(line.Chords) + "\n")
+			}
+			if strings.TrimSpace(line.Lyrics) != "" || len(line.Chords) == 0 {
+				b.WriteString(line.Lyrics + "\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func sectionTitle(section models.ChordSection) string {
+	if section.Label != "" {
+		return section.Label
+	}
+
+	title := strings.ToUpper(section.Type[:1]) + section.Type[1:]
+	if section.Type == lyrics.SectionVerse {
+		title += fmt.Sprintf(" %d", section.Number)
+	}
+
+	return title
+}
+
+// chordLine places chords at their positions, moving a chord right when the
+// one before it is too long to leave room.
+func chordLine(chords []models.ChordPosition) string {
+	var b strings.Builder
+	column := 0
+
+	for _, c := range chords {
+		position := max(c.Position, column)
+		if column > 0 && position == column {
+			position++
+		}
+
+		b.WriteString(strings.Repeat(" ", position-column))
+		b.WriteString(c.Chord)
+
+		column = position + utf8.RuneCountInString(c.Chord)
+	}
+
+	return b.String()
+}
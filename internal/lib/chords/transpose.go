@@ -0,0 +1,100 @@
+package chords
+
+import (
+	"regexp"
+	"strings"
+
+	"effective_mobile/internal/domain/models"
+)
+
+var (
+	chordPattern = regexp.MustCompile(`^([A-G])([#b]?)([0-9a-zA-Z+\-#°ø()^]*)(?:/([A-G])([#b]?))?$`)
+
+	sharpNotes = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNotes  = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+	naturalNotes = map[string]int{"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11}
+)
+
+// noChord are the ways sheets mark a place where nothing is played.
+var noChord = map[string]struct{}{"N.C.": {}, "NC": {}, "N.C": {}, "x": {}}
+
+// IsChord reports whether s is a chord name like "F#m7/C#" or a no-chord mark.
+func IsChord(s string) bool {
+	if _, ok := noChord[s]; ok {
+		return true
+	}
+
+	return chordPattern.MatchString(s)
+}
+
+// Shift transposes the chords of sheet for transpose semitones and a capo
+// at fret capo. The chords of a sheet are the shapes played with its own
+// capo, so the shapes for the new capo are moved by the difference of the
+// capos as well. The key is the sounding key and only moves by transpose.
+func Shift(sheet models.ChordSheet, transpose, capo int) models.ChordSheet {
+	shift := transpose + sheet.Capo - capo
+
+	sections := make([]models.ChordSection, len(sheet.Sections))
+	for i, section := range sheet.Sections {
+		lines := make([]models.ChordLine, len(section.Lines))
+		for j, line := range section.Lines {
+			lines[j] = line
+			if len(line.Chords) == 0 {
+				continue
+			}
+
+			lines[j].Chords = make([]models.ChordPosition, len(line.Chords))
+			for k, c := range line.Chords {
+				lines[j].Chords[k] = models.ChordPosition{Chord: Transpose(c.Chord, shift), Position: c.Position}
+			}
+		}
+
+		section.Lines = lines
+		sections[i] = section
+	}
+
+	sheet.Sections = sections
+	sheet.Key = Transpose(sheet.Key, transpose)
+	sheet.Capo = capo
+	sheet.Transpose = transpose
+
+	return sheet
+}
+
+// Transpose moves chord by semitones, keeping its quality and bass note.
+// Chords written with flats stay with flats, the others use sharps.
+func Transpose(chord string, semitones int) string {
+	match := chordPattern.FindStringSubmatch(chord)
+	if match == nil || semitones%12 == 0 {
+		return chord
+	}
+
+	notes := sharpNotes
+	if match[2] == "b" || match[5] == "b" {
+		notes = flatNotes
+	}
+
+	var b strings.Builder
+	b.WriteString(transposeNote(notes, match[1], match[2], semitones))
+	b.WriteString(match[3])
+
+	if match[4] != "" {
+		b.WriteString("/")
+		b.WriteString(transposeNote(notes, match[4], match[5], semitones))
+	}
+
+	return b.String()
+}
+
+func transposeNote(notes []string, natural, accidental string, semitones int) string {
+	n := naturalNotes[natural]
+	switch accidental {
+	case "#":
+		n++
+	case "b":
+		n--
+	}
+
+	return notes[((n+semitones)%12+12)%12]
+}
@@ -0,0 +1,152 @@
+package dedup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		group string
+		song  string
+		want  string
+	}{
+		{"case and spacing", "  MUSE ", "Supermassive   Black Hole", "muse - supermassive black hole"},
+		{"punctuation", "AC/DC", "Back In Black!", "ac dc - back in black"},
+		{"ampersand", "Simon & Garfunkel", "The Boxer", "simon and garfunkel - the boxer"},
+		{"leading the of the group only", "The Beatles", "The Long and Winding Road", "beatles - the long and winding road"},
+		{"diacritics", "Beyoncé", "Déjà Vu", "beyonce - deja vu"},
+		{"feat. in the group", "Eminem feat. Rihanna", "Love the Way You Lie", "eminem - love the way you lie"},
+		{"ft in the title", "Daft Punk", "Get Lucky ft Pharrell Williams", "daft punk - get lucky"},
+		{"bracketed with credit", "Lizzo", "Good as Hell (with Ariana Grande)", "lizzo - good as hell"},
+		{"with in a title is kept", "Muse", "Stay With Me", "muse - stay with me"},
+		{"bracketed version", "Queen", "Bohemian Rhapsody [Remastered 2011]", "queen - bohemian rhapsody"},
+		{"dashed version", "Queen", "Under Pressure - Live at Wembley", "queen - under pressure"},
+		{"brackets without a version are kept", "Muse", "Knights of Cydonia (Part 2)", "muse - knights of cydonia part 2"},
+		{"cyrillic", "Кино", "Группа крови (Live)", "кино - группа крови"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalKey(tt.group, tt.song); got != tt.want {
+				t.Errorf("CanonicalKey(%q, %q) = %q, want %q", tt.group, tt.song, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalKeyKeepsScript(t *testing.T) {
+	// Transliteration is left to search: "Кино" and "Kino" are told apart.
+	if CanonicalKey("Кино", "Звезда") == CanonicalKey("Kino", "Zvezda") {
+		t.Error("CanonicalKey() of names in different scripts are equal")
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	tests := []struct {
+		group string
+		want  string
+	}{
+		{"The Rolling Stones", "rolling stones"},
+		{"Theory of a Deadman", "theory of a deadman"},
+		{"Mötley Crüe", "motley crue"},
+		{"Jay-Z featuring Alicia Keys", "jay z"},
+	}
+
+	for _, tt := range tests {
+		if got := GroupKey(tt.group); got != tt.want {
+			t.Errorf("GroupKey(%q) = %q, want %q", tt.group, got, tt.want)
+		}
+
+		if got := CanonicalKey(tt.group, "Song"); !strings.HasPrefix(got, tt.want+" - ") {
+			t.Errorf("CanonicalKey(%q, ...) = %q, want the group part %q", tt.group, got, tt.want)
+		}
+	}
+}
+
+const lyricsText = `Ooh baby, don't you know I suffer?
+Ooh baby, can you hear me moan?
+You caught me under false pretenses
+How long before you let me go?
+
+You set my soul alight
+You set my soul alight
+
+Glaciers melting in the dead of night
+And the superstars sucked into the supermassive
+You set my soul alight`
+
+func TestFingerprint(t *testing.T) {
+	fp, ok := Fingerprint(lyricsText)
+	if !ok {
+		t.Fatal("Fingerprint() found no words")
+	}
+
+	if len(fp.MinHash) != NumHashes || len(fp.Bands) != Bands {
+		t.Fatalf("Fingerprint() has %d hashes and %d bands, want %d and %d", len(fp.MinHash), len(fp.Bands), NumHashes, Bands)
+	}
+
+	same := []struct {
+		name string
+		text string
+	}{
+		{"case and punctuation", strings.ToUpper(strings.NewReplacer(",", "", "?", "!").Replace(lyricsText))},
+		{"section markers", "[Verse 1]\n" + lyricsText},
+		{"typographic apostrophes", strings.ReplaceAll(lyricsText, "'", "’")},
+	}
+
+	for _, tt := range same {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := Fingerprint(tt.text)
+			if !reflect.DeepEqual(got, fp) {
+				t.Errorf("Fingerprint() differs for %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestFingerprintWithoutWords(t *testing.T) {
+	for _, text := range []string{"", "\n\n", "[Chorus]", "... !!!"} {
+		if fp, ok := Fingerprint(text); ok || len(fp.MinHash) != 0 {
+			t.Errorf("Fingerprint(%q) = %v, %t, want none", text, fp, ok)
+		}
+	}
+}
+
+func TestFingerprintShortLyrics(t *testing.T) {
+	// Lyrics shorter than a shingle still get a fingerprint.
+	if _, ok := Fingerprint("Hello"); !ok {
+		t.Error("Fingerprint() of a single word found no words")
+	}
+}
+
+func TestFingerprintBands(t *testing.T) {
+	fp, _ := Fingerprint(lyricsText)
+
+	// A typo and a dropped line leave most shingles in place.
+	near, _ := Fingerprint(strings.Replace(strings.Replace(lyricsText, "pretenses", "pretences", 1), "You set my soul alight\n\n", "", 1))
+	if sharedBands(fp.Bands, near.Bands) == 0 {
+		t.Error("near-identical lyrics share no band")
+	}
+
+	other, _ := Fingerprint(`Is this the real life? Is this just fantasy?
+Caught in a landslide, no escape from reality
+Open your eyes, look up to the skies and see
+I'm just a poor boy, I need no sympathy`)
+	if n := sharedBands(fp.Bands, other.Bands); n != 0 {
+		t.Errorf("different lyrics share %d bands", n)
+	}
+}
+
+func sharedBands(a, b []int64) int {
+	shared := 0
+	for i := range a {
+		if a[i] == b[i] {
+			shared++
+		}
+	}
+
+	return shared
+}
@@ -0,0 +1,72 @@
+// Package dedup finds songs that are likely the same: by a canonical key of
+// group and title, and by the similarity of their lyrics.
+package dedup
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// versionWords mark a bracketed or dashed part of a title as a version
+	// of the song rather than a different song: "(Live)", "[Remastered
+	// 2011]", "- Radio Edit".
+	versionWords = `live|remaster(?:ed)?|radio edit|edit|single version|album version|version|mono|stereo|` +
+		`explicit|clean|bonus track|deluxe|anniversary edition|demo`
+
+	bracketedVersion = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*\b(?:` + versionWords + `)\b[^)\]]*[)\]]`)
+	dashedVersion    = regexp.MustCompile(`(?i)\s+[-–—]\s+[^-–—]*\b(?:` + versionWords + `)\b.*$`)
+	// featuring matches a credit to the end of the name. "with" only counts
+	// in brackets, as it is common in titles.
+	featuring  = regexp.MustCompile(`(?i)\s*(?:[(\[]\s*(?:feat|ft|featuring|with)\b|\b(?:feat|ft|featuring)\b\.?\s).*$`)
+	leadingThe = regexp.MustCompile(`^the\s+`)
+)
+
+// stripMarks removes diacritics, so "Beyoncé" and "Beyonce" compare equal.
+// Chains keep state, so every call gets its own.
+func stripMarks() transform.Transformer {
+	return transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+}
+
+// CanonicalKey returns the key group and song are compared by. Case,
+// punctuation, diacritics, a leading "The", "feat." credits and version
+// suffixes such as "(Live)" or "- Remastered 2009" do not change it.
+func CanonicalKey(group, song string) string {
+	return normalizeName(group, true) + " - " + normalizeName(song, false)
+}
+
+// GroupKey returns the key group names are compared by, the group part of
+// CanonicalKey.
+func GroupKey(group string) string {
+	return normalizeName(group, true)
+}
+
+func normalizeName(name string, isGroup bool) string {
+	name = featuring.ReplaceAllString(name, "")
+
+	if !isGroup {
+		name = bracketedVersion.ReplaceAllString(name, "")
+		name = dashedVersion.ReplaceAllString(name, "")
+	}
+
+	if stripped, _, err := transform.String(stripMarks(), name); err == nil {
+		name = stripped
+	}
+
+	name = strings.ToLower(strings.ReplaceAll(name, "&", " and "))
+
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+
+	if isGroup {
+		name = leadingThe.ReplaceAllString(name, "")
+	}
+
+	return name
+}
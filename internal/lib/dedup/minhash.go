@@ -0,0 +1,116 @@
+package dedup
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/lyrics"
+	"effective_mobile/internal/lib/wordstats"
+)
+
+const (
+	// NumHashes is the length of a MinHash signature. The share of equal
+	// positions of two signatures estimates the Jaccard similarity of the
+	// shingles of two lyrics to about ±0.06.
+	NumHashes = 64
+	// Bands and rows split a signature for locality-sensitive hashing:
+	// lyrics share a band with probability 1-(1-s^rows)^bands, which is
+	// above 0.5 from a similarity of about 0.5 and near 1 from 0.75.
+	Bands = 16
+	rows  = NumHashes / Bands
+
+	shingleSize = 3
+)
+
+// seeds derive the NumHashes hash functions from one 64-bit hash. They are
+// fixed, as stored signatures are only comparable with the same seeds.
+var seeds = func() [NumHashes]uint64 {
+	var seeds [NumHashes]uint64
+
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		x = splitmix(x)
+		seeds[i] = x
+	}
+
+	return seeds
+}()
+
+// Fingerprint returns the MinHash signature of the shingles of lyrics and
+// its band hashes. Shingles are runs of three words of the lyrics as sung,
+// without section markers. Lyrics without words have no fingerprint.
+func Fingerprint(text string) (models.Fingerprint, bool) {
+	var b strings.Builder
+	for _, section := range lyrics.Parse(text) {
+		b.WriteString(section.Text)
+		b.WriteByte('\n')
+	}
+
+	words := wordstats.Words(b.String())
+	if len(words) == 0 {
+		return models.Fingerprint{}, false
+	}
+
+	signature := make([]uint64, NumHashes)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	for i := 0; i+shingleSize <= len(words) || i == 0; i++ {
+		end := min(i+shingleSize, len(words))
+		h := hashString(strings.Join(words[i:end], " "))
+
+		for j, seed := range seeds {
+			if v := splitmix(h ^ seed); v < signature[j] {
+				signature[j] = v
+			}
+		}
+	}
+
+	fp := models.Fingerprint{
+		MinHash: make([]int64, NumHashes),
+		Bands:   make([]int64, Bands),
+	}
+
+	for i, v := range signature {
+		fp.MinHash[i] = int64(v)
+	}
+
+	buf := make([]byte, 8*(rows+1))
+	for band := 0; band < Bands; band++ {
+		// The band number is hashed in, so equal values in different bands
+		// do not collide.
+		binary.LittleEndian.PutUint64(buf, uint64(band))
+		for r := 0; r < rows; r++ {
+			binary.LittleEndian.PutUint64(buf[8*(r+1):], signature[band*rows+r])
+		}
+
+		fp.Bands[band] = int64(hashBytes(buf))
+	}
+
+	return fp, true
+}
+
+func hashString(s string) uint64 {
+	return hashBytes([]byte(s))
+}
+
+func hashBytes(b []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+
+	return h.Sum64()
+}
+
+// splitmix is the finalizer of SplitMix64, which turns a hash into an
+// independent looking one.
+func splitmix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+
+	return x ^ (x >> 31)
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "OK"
+	StatusFail = "Error"
+)
+
+// ErrShuttingDown is reported for every check once Drain was called.
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Checker runs the readiness checks of the service.
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+// New returns a checker giving every check at most timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+	}
+}
+
+// Register adds a check under name. It must not be called once the checker
+// is in use.
+func (c *Checker) Register(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks[name] = check
+}
+
+// Drain makes the service report itself not ready from now on, so load
+// balancers stop routing to it before the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs every check concurrently and reports the result of each.
+func (c *Checker) Ready(ctx context.Context) (map[string]Result, bool) {
+	results := make(map[string]Result, len(c.names))
+
+	if c.draining.Load() {
+		results["shutdown"] = Result{Status: StatusFail, Error: ErrShuttingDown.Error(), Duration: "0s"}
+
+		return results, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	ready := true
+
+	for _, name := range c.names {
+		wg.Add(1)
+
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := check(ctx)
+
+			result := Result{Status: StatusOK, Duration: time.Since(start).Round(time.Microsecond).String()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[name] = result
+			if err != nil {
+				ready = false
+			}
+		}(name, c.checks[name])
+	}
+
+	wg.Wait()
+
+	return results, ready
+}
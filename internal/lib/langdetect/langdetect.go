@@ -0,0 +1,223 @@
+// Package langdetect guesses the language of lyrics offline, from the
+// letter n-grams of the text.
+//
+// Every supported language has a small corpus embedded in the binary. The
+// frequencies of its one to three letter n-grams make a naive Bayes model:
+// a text is scored by how likely its n-grams are in every language. The
+// confidence compares the average score of an n-gram across languages, so
+// it does not grow with the length of the text, and is scaled down by the
+// share of letters in another script, as in Russian lyrics with English
+// lines.
+package langdetect
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"effective_mobile/internal/domain/models"
+)
+
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+const (
+	maxN = 3
+	// minLetters is the shortest text, in letters, a language is guessed
+	// for. Shorter ones are mostly names and interjections.
+	minLetters = 12
+	// maxNGrams caps the n-grams scored, so long lyrics cost no more than
+	// a few verses.
+	maxNGrams = 3000
+	// sharpness scales the differences of the average n-gram scores before
+	// they are turned into a confidence. Higher values make it reach 1
+	// sooner.
+	sharpness = 10
+)
+
+type profile struct {
+	lang string
+	// logProb is the smoothed log probability of every n-gram of the
+	// corpus; unseen holds that of n-grams it does not contain.
+	logProb map[string]float64
+	unseen  float64
+	// script is the script most letters of the corpus are written in.
+	script *unicode.RangeTable
+}
+
+var profiles []profile
+
+func init() {
+	entries, err := corpus.ReadDir("corpus")
+	if err != nil {
+		panic(err)
+	}
+
+	counts := make(map[string]map[string]int, len(entries))
+	vocabulary := make(map[string]struct{})
+
+	for _, e := range entries {
+		data, err := corpus.ReadFile(path.Join("corpus", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		c := make(map[string]int)
+		for _, g := range ngrams(string(data), -1) {
+			c[g]++
+			vocabulary[g] = struct{}{}
+		}
+
+		counts[strings.TrimSuffix(e.Name(), ".txt")] = c
+	}
+
+	// Add-one smoothing over the n-grams of all corpora, so an n-gram
+	// missing from one corpus costs the same in every language.
+	for lang, c := range counts {
+		total := len(vocabulary)
+		for _, n := range c {
+			total += n
+		}
+
+		p := profile{
+			lang:    lang,
+			logProb: make(map[string]float64, len(c)),
+			unseen:  math.Log(1 / float64(total)),
+			script:  mainScript(c),
+		}
+
+		for g, n := range c {
+			p.logProb[g] = math.Log(float64(n+1) / float64(total))
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].lang < profiles[j].lang })
+}
+
+// Languages returns the base language tags that can be detected, sorted.
+func Languages() []string {
+	langs := make([]string, len(profiles))
+	for i, p := range profiles {
+		langs[i] = p.lang
+	}
+
+	return langs
+}
+
+// Detect guesses the language of text. The result is empty when text has
+// too few letters to tell.
+func Detect(text string) models.DetectedLanguage {
+	grams := ngrams(text, maxNGrams)
+
+	letters := make(map[*unicode.RangeTable]int)
+	total := 0
+
+	for _, g := range grams {
+		if r := []rune(g); len(r) == 1 {
+			letters[scriptOf(r[0])]++
+			total++
+		}
+	}
+
+	if total < minLetters {
+		return models.DetectedLanguage{}
+	}
+
+	scores := make([]float64, len(profiles))
+	best := 0
+
+	for i, p := range profiles {
+		for _, g := range grams {
+			if lp, ok := p.logProb[g]; ok {
+				scores[i] += lp
+			} else {
+				scores[i] += p.unseen
+			}
+		}
+
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp((score - scores[best]) / float64(len(grams)) * sharpness)
+	}
+
+	share := float64(letters[profiles[best].script]) / float64(total)
+
+	return models.DetectedLanguage{
+		Lang:       profiles[best].lang,
+		Confidence: math.Round(share/sum*1000) / 1000,
+	}
+}
+
+var scripts = []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek}
+
+// scriptOf returns the script of letter r, or nil for scripts no corpus is
+// written in.
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, script := range scripts {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+
+	return nil
+}
+
+func mainScript(counts map[string]int) *unicode.RangeTable {
+	letters := make(map[*unicode.RangeTable]int)
+
+	var main *unicode.RangeTable
+	for g, n := range counts {
+		r := []rune(g)
+		if len(r) != 1 {
+			continue
+		}
+
+		script := scriptOf(r[0])
+		letters[script] += n
+
+		if letters[script] > letters[main] {
+			main = script
+		}
+	}
+
+	return main
+}
+
+// ngrams returns the one to maxN letter n-grams of the words of text, the
+// longer ones padded with a space at word boundaries, up to limit of them
+// or all with a negative limit. Case, digits and punctuation are ignored.
+func ngrams(text string, limit int) []string {
+	var grams []string
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		runes := []rune(" " + word + " ")
+
+		for n := 1; n <= maxN; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				g := string(runes[i : i+n])
+				if g == " " {
+					continue
+				}
+
+				grams = append(grams, g)
+				if limit >= 0 && len(grams) >= limit {
+					return grams
+				}
+			}
+		}
+	}
+
+	return grams
+}
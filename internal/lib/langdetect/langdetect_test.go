@@ -0,0 +1,114 @@
+package langdetect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLanguages(t *testing.T) {
+	want := []string{"de", "en", "es", "fr", "it", "pt", "ru", "uk"}
+
+	if got := Languages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages() = %v, want %v", got, want)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	// Samples are a line or two of lyrics, as short as a verse gets.
+	tests := []struct {
+		lang string
+		text string
+	}{
+		{"en", "I walk a lonely road, the only one that I have ever known"},
+		{"en", "Hello darkness, my old friend, I've come to talk with you again"},
+		{"de", "Du hast mich gefragt und ich hab nichts gesagt"},
+		{"de", "Ich will, dass ihr mir vertraut, ich will, dass ihr mir glaubt"},
+		{"es", "Despacito, quiero respirar tu cuello despacito"},
+		{"es", "Vivir la vida sin pensar en el mañana, bailando hasta que salga el sol"},
+		{"fr", "Non, rien de rien, non, je ne regrette rien"},
+		{"fr", "Quand il me prend dans ses bras, il me parle tout bas"},
+		{"it", "Nel blu dipinto di blu, felice di stare lassù"},
+		{"it", "Ancora tu, non mi sorprende lo sai, ancora tu"},
+		{"pt", "Olha que coisa mais linda, mais cheia de graça"},
+		{"pt", "Eu sei que vou te amar, por toda a minha vida eu vou te amar"},
+		{"ru", "Группа крови на рукаве, мой порядковый номер на рукаве"},
+		{"ru", "Я свободен, словно птица в вышине"},
+		{"uk", "Червона рута, не шукай вечорами"},
+		{"uk", "Ой у лузі червона калина похилилася, чогось наша славна Україна зажурилася"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got := Detect(tt.text)
+
+			if got.Lang != tt.lang {
+				t.Errorf("Detect(%q) = %s (%.3f), want %s", tt.text, got.Lang, got.Confidence, tt.lang)
+			}
+
+			if got.Confidence <= 0 || got.Confidence > 1 {
+				t.Errorf("Detect(%q) confidence = %v, want in (0, 1]", tt.text, got.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetectClosePairs(t *testing.T) {
+	// Languages close enough to share most n-grams are told apart by the
+	// letters and words only one of them has.
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"ru not uk", "Мы ждём перемен, перемен требуют наши сердца", "ru"},
+		{"uk not ru", "Ще не вмерла України і слава, і воля", "uk"},
+		{"uk with і and ї", "Їхали козаки із Дону додому, підманули Галю", "uk"},
+		{"es not pt", "Bésame, bésame mucho, como si fuera esta noche la última vez", "es"},
+		{"pt not es", "Não sei se é um sonho ou se é verdade, coração", "pt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.text); got.Lang != tt.want {
+				t.Errorf("Detect(%q) = %s (%.3f), want %s", tt.text, got.Lang, got.Confidence, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectTooShort(t *testing.T) {
+	for _, text := range []string{"", "   ", "Oh yeah", "12345 67890 !!!", "Ла-ла-ла"} {
+		if got := Detect(text); got.Lang != "" || got.Confidence != 0 {
+			t.Errorf("Detect(%q) = %+v, want nothing", text, got)
+		}
+	}
+}
+
+func TestDetectMixedScripts(t *testing.T) {
+	russian := "Я свободен, словно птица в вышине, я свободен, я забыл, что значит страх"
+	mixed := russian + " Yeah yeah, baby, come on"
+
+	pure, withEnglish := Detect(russian), Detect(mixed)
+
+	if withEnglish.Lang != "ru" {
+		t.Fatalf("Detect(mixed) = %s, want ru", withEnglish.Lang)
+	}
+
+	if withEnglish.Confidence >= pure.Confidence {
+		t.Errorf("confidence with English lines = %.3f, want less than %.3f", withEnglish.Confidence, pure.Confidence)
+	}
+}
+
+func TestDetectConfidenceDoesNotGrowWithLength(t *testing.T) {
+	line := "I walk a lonely road, the only one that I have ever known. "
+
+	short, long := Detect(line), Detect(line+line+line+line)
+
+	if short.Lang != "en" || long.Lang != "en" {
+		t.Fatalf("Detect() = %s and %s, want en", short.Lang, long.Lang)
+	}
+
+	if diff := long.Confidence - short.Confidence; diff > 0.05 || diff < -0.05 {
+		t.Errorf("confidence = %.3f for one line and %.3f for four, want about the same", short.Confidence, long.Confidence)
+	}
+}
@@ -0,0 +1,73 @@
+package locale
+
+import (
+	"errors"
+
+	"golang.org/x/text/language"
+)
+
+var ErrInvalidTag = errors.New("invalid language tag")
+
+// Canonical parses a BCP-47 language tag and returns its canonical form,
+// so "en-us" and "EN-US" are both stored as "en-US".
+func Canonical(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", ErrInvalidTag
+	}
+
+	return t.String(), nil
+}
+
+// Base returns the base language of a BCP-47 tag, so "pt-BR" gives "pt".
+// Detected languages are stored as base languages.
+func Base(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil || t == language.Und {
+		return "", ErrInvalidTag
+	}
+
+	base, _ := t.Base()
+
+	return base.String(), nil
+}
+
+// Negotiate picks the language of available that best serves the client.
+// An explicit lang wins over acceptLanguage, the value of an
+// Accept-Language header. Only close matches count, so "en" matches "en-GB"
+// but "pt" never falls back to "es". ok is false when nothing matches.
+func Negotiate(available []string, lang, acceptLanguage string) (string, bool) {
+	if len(available) == 0 {
+		return "", false
+	}
+
+	var wanted []language.Tag
+
+	if lang != "" {
+		t, err := language.Parse(lang)
+		if err != nil {
+			return "", false
+		}
+
+		wanted = []language.Tag{t}
+	} else {
+		tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+		if err != nil || len(tags) == 0 {
+			return "", false
+		}
+
+		wanted = tags
+	}
+
+	supported := make([]language.Tag, 0, len(available))
+	for _, a := range available {
+		supported = append(supported, language.Make(a))
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(wanted...)
+	if confidence < language.High {
+		return "", false
+	}
+
+	return available[index], true
+}
@@ -0,0 +1,162 @@
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const redacted = "[REDACTED]"
+
+// Handler masks attributes whose key is on the redaction list and truncates
+// long string values before passing records on. Struct and map values are
+// flattened into groups through their JSON form, so their fields are
+// checked as well.
+type Handler struct {
+	next   slog.Handler
+	keys   map[string]struct{}
+	maxLen int
+}
+
+// NewHandler wraps next. Keys are matched case-insensitively; a maxLen of 0
+// disables truncation.
+func NewHandler(next slog.Handler, keys []string, maxLen int) *Handler {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			set[strings.ToLower(k)] = struct{}{}
+		}
+	}
+
+	return &Handler{next: next, keys: set, maxLen: maxLen}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, h.truncate(r.Message), r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(h.attr(a))
+
+		return true
+	})
+
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		clean = append(clean, h.attr(a))
+	}
+
+	return &Handler{next: h.next.WithAttrs(clean), keys: h.keys, maxLen: h.maxLen}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{next: h.next.WithGroup(name), keys: h.keys, maxLen: h.maxLen}
+}
+
+func (h *Handler) attr(a slog.Attr) slog.Attr {
+	if _, ok := h.keys[strings.ToLower(a.Key)]; ok {
+		return slog.String(a.Key, redacted)
+	}
+
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.truncate(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		clean := make([]slog.Attr, 0, len(attrs))
+		for _, ga := range attrs {
+			clean = append(clean, h.attr(ga))
+		}
+
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	case slog.KindAny:
+		if v.Any() == nil {
+			return slog.Attr{Key: a.Key, Value: v}
+		}
+
+		if _, isErr := v.Any().(error); isErr {
+			return slog.String(a.Key, h.truncate(v.String()))
+		}
+
+		// Only objects and strings are walked again; anything else would
+		// flatten to itself.
+		flat := flatten(v.Any())
+		if k := flat.Kind(); k != slog.KindGroup && k != slog.KindString {
+			return slog.Attr{Key: a.Key, Value: flat}
+		}
+
+		return h.attr(slog.Attr{Key: a.Key, Value: flat})
+	default:
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
+
+func (h *Handler) truncate(s string) string {
+	if h.maxLen <= 0 || len(s) <= h.maxLen {
+		return s
+	}
+
+	cut := h.maxLen
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s...(%d bytes total)", s[:cut], len(s))
+}
+
+// flatten turns an arbitrary value into a group through its JSON form.
+// Values that are not JSON objects are kept as they are.
+func flatten(v interface{}) slog.Value {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return slog.AnyValue(v)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return slog.AnyValue(v)
+	}
+
+	return jsonValue(decoded)
+}
+
+func jsonValue(v interface{}) slog.Value {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, k := range keys {
+			attrs = append(attrs, slog.Attr{Key: k, Value: jsonValue(t[k])})
+		}
+
+		return slog.GroupValue(attrs...)
+	case string:
+		return slog.StringValue(t)
+	case float64:
+		return slog.Float64Value(t)
+	case bool:
+		return slog.BoolValue(t)
+	case nil:
+		return slog.AnyValue(nil)
+	default:
+		return slog.AnyValue(t)
+	}
+}
@@ -0,0 +1,31 @@
+package lyrics
+
+// AlignedVerses returns the verses of a translation numbered like the verses
+// of the original, so verse N of both is the same part of the song.
+//
+// When both split into the same number of sections, the translation is
+// aligned section by section and its verses are the sections that are
+// verses in the original, whatever the translation's own repeats suggest.
+// Otherwise the translation's own verses are returned, and aligned reports
+// whether their count still matches the original's.
+func AlignedVerses(original, translation string) (verses []string, originalCount int, aligned bool) {
+	originalSections := Parse(original)
+	translationSections := Parse(translation)
+
+	originalCount = len(Verses(originalSections))
+
+	if len(originalSections) == len(translationSections) {
+		verses = []string{}
+		for i, s := range originalSections {
+			if s.Type == SectionVerse {
+				verses = append(verses, translationSections[i].Text)
+			}
+		}
+
+		return verses, originalCount, true
+	}
+
+	verses = Verses(translationSections)
+
+	return verses, originalCount, len(verses) == originalCount
+}
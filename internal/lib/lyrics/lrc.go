@@ -0,0 +1,207 @@
+package lyrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"effective_mobile/internal/domain/models"
+)
+
+var ErrInvalidLRC = errors.New("invalid lrc")
+
+var (
+	// timestampLine matches a lyric line with one or more leading
+	// timestamps, as a line sung several times is often written once.
+	timestampLine = regexp.MustCompile(`^((?:\[\d{1,3}:\d{2}(?:[.:]\d{1,3})?\]\s*)+)(.*)$`)
+	timestamp     = regexp.MustCompile(`\[(\d{1,3}):(\d{2})(?:[.:](\d{1,3}))?\]`)
+	metadataLine  = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseLRC parses lyrics in LRC format, one "[mm:ss.xx] text" line per
+// lyric line. Metadata tags such as [ar:...] are skipped, except [offset:]
+// which shifts every timestamp by the given milliseconds. Timestamps must
+// not decrease, and a line with an empty text marks a pause between verses.
+//
+// A line with several timestamps, "[00:12.00][00:45.00]Chorus", is sung at
+// each of them, which must increase. It stands for lines all over the song,
+// so it is not held to the order of the lines around it; instead, its
+// timed lines are sorted in among the others.
+func ParseLRC(text string) ([]models.TimedLine, error) {
+	var lines []models.TimedLine
+	shift := 0
+	repeated := false
+	// previous is the timestamp of the last line with a single one.
+	previous := -1
+
+	for n, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		if match := timestampLine.FindStringSubmatch(line); match != nil {
+			stamps := timestamp.FindAllStringSubmatch(match[1], -1)
+			lyric := strings.TrimSpace(match[2])
+
+			offsets := make([]int, len(stamps))
+			for i, stamp := range stamps {
+				offset, err := parseTimestamp(stamp[1], stamp[2], stamp[3])
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidLRC, n+1, err)
+				}
+
+				if i > 0 && offset < offsets[i-1] {
+					return nil, fmt.Errorf("%w: line %d: timestamp goes back in time", ErrInvalidLRC, n+1)
+				}
+
+				offsets[i] = offset
+			}
+
+			if len(offsets) == 1 {
+				if offsets[0] < previous {
+					return nil, fmt.Errorf("%w: line %d: timestamp goes back in time", ErrInvalidLRC, n+1)
+				}
+
+				previous = offsets[0]
+			} else {
+				repeated = true
+			}
+
+			for _, offset := range offsets {
+				lines = append(lines, models.TimedLine{
+					OffsetMS: offset,
+					Text:     lyric,
+				})
+			}
+
+			continue
+		}
+
+		if match := metadataLine.FindStringSubmatch(line); match != nil {
+			if strings.EqualFold(match[1], "offset") {
+				value, err := strconv.Atoi(strings.TrimSpace(match[2]))
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: invalid offset", ErrInvalidLRC, n+1)
+				}
+
+				shift = value
+			}
+
+			continue
+		}
+
+		return nil, fmt.Errorf("%w: line %d: missing [mm:ss.xx] timestamp", ErrInvalidLRC, n+1)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no timed lines", ErrInvalidLRC)
+	}
+
+	// Lines with a single timestamp are in order already, so a stable sort
+	// only places the lines sung several times.
+	if repeated {
+		sort.SliceStable(lines, func(i, j int) bool { return lines[i].OffsetMS < lines[j].OffsetMS })
+	}
+
+	// A positive [offset:] means the lyrics come earlier.
+	for i := range lines {
+		lines[i].OffsetMS = max(lines[i].OffsetMS-shift, 0)
+	}
+
+	return lines, nil
+}
+
+// FormatLRC writes lines back in LRC format with centisecond timestamps.
+func FormatLRC(lines []models.TimedLine) string {
+	var b strings.Builder
+
+	for _, line := range lines {
+		fmt.Fprintf(&b, "[%s]%s\n", formatTimestamp(line.OffsetMS), prefixSpace(line.Text))
+	}
+
+	return b.String()
+}
+
+// PlainText is the lyrics text of timed lines. Empty lines separate verses,
+// so verse paging over the text works the same as for plain lyrics.
+func PlainText(lines []models.TimedLine) string {
+	var blocks []string
+	var current []string
+
+	for _, line := range lines {
+		if line.Text == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+
+			continue
+		}
+
+		current = append(current, line.Text)
+	}
+
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// ActiveLine returns the line being sung offsetMS into the song: the last
+// line that started at or before it.
+func ActiveLine(lines []models.TimedLine, offsetMS int) models.ActiveLine {
+	i := sort.Search(len(lines), func(i int) bool {
+		return lines[i].OffsetMS > offsetMS
+	})
+
+	active := models.ActiveLine{Index: i - 1}
+	if i > 0 {
+		active.Line = &lines[i-1]
+	}
+	if i < len(lines) {
+		next := lines[i].OffsetMS
+		active.NextOffsetMS = &next
+	}
+
+	return active
+}
+
+func parseTimestamp(minutes, seconds, fraction string) (int, error) {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	if s >= 60 {
+		return 0, fmt.Errorf("seconds out of range: %d", s)
+	}
+
+	ms := 0
+	if fraction != "" {
+		f, _ := strconv.Atoi(fraction)
+		switch len(fraction) {
+		case 1:
+			ms = f * 100
+		case 2:
+			ms = f * 10
+		default:
+			ms = f
+		}
+	}
+
+	return (m*60+s)*1000 + ms, nil
+}
+
+func formatTimestamp(offsetMS int) string {
+	return fmt.Sprintf("%02d:%02d.%02d", offsetMS/60000, offsetMS/1000%60, offsetMS%1000/10)
+}
+
+func prefixSpace(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	return " " + text
+}
@@ -0,0 +1,84 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"effective_mobile/internal/domain/models"
+)
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+		want []models.TimedLine
+	}{
+		{
+			name: "one timestamp per line",
+			lrc:  "[ar:Muse]\n[00:01.50]First\n[00:03.25] Second\n[00:05.00]",
+			want: []models.TimedLine{{OffsetMS: 1500, Text: "First"}, {OffsetMS: 3250, Text: "Second"}, {OffsetMS: 5000}},
+		},
+		{
+			name: "repeated line is sung at each timestamp",
+			lrc:  "[00:12.00][00:45.00]Chorus line\n[00:05.00]Intro\n[00:20.00]Verse two",
+			want: []models.TimedLine{
+				{OffsetMS: 5000, Text: "Intro"},
+				{OffsetMS: 12000, Text: "Chorus line"},
+				{OffsetMS: 20000, Text: "Verse two"},
+				{OffsetMS: 45000, Text: "Chorus line"},
+			},
+		},
+		{
+			name: "offset shifts every timestamp",
+			lrc:  "[offset:500]\n[00:01.00]First\n[00:00.20][00:02.00]Second",
+			want: []models.TimedLine{{OffsetMS: 0, Text: "Second"}, {OffsetMS: 500, Text: "First"}, {OffsetMS: 1500, Text: "Second"}},
+		},
+		{
+			name: "negative offset delays the lyrics",
+			lrc:  "[offset:-250]\n[00:01.00]First",
+			want: []models.TimedLine{{OffsetMS: 1250, Text: "First"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLRC(tt.lrc)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLRCInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+		want string
+	}{
+		{"missing timestamp", "[00:01.00]First\nSecond", "invalid lrc: line 2: missing [mm:ss.xx] timestamp"},
+		{"goes back", "[00:05.00]First\n[00:01.00]Second", "invalid lrc: line 2: timestamp goes back in time"},
+		{"single line goes back next to a repeated one", "[00:10.00][00:50.00]Chorus\n[01:20.00]Verse\n[00:05.00]Typo", "invalid lrc: line 3: timestamp goes back in time"},
+		{"repeated goes back", "[00:45.00][00:12.00]Chorus", "invalid lrc: line 1: timestamp goes back in time"},
+		{"invalid offset", "[offset:soon]\n[00:01.00]First", "invalid lrc: line 1: invalid offset"},
+		{"no lines", "[ar:Muse]", "invalid lrc: no timed lines"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLRC(tt.lrc)
+			if !errors.Is(err, ErrInvalidLRC) {
+				t.Fatalf("ParseLRC() error = %v, want ErrInvalidLRC", err)
+			}
+
+			if err.Error() != tt.want {
+				t.Errorf("ParseLRC() error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
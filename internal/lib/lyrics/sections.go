@@ -0,0 +1,276 @@
+package lyrics
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"effective_mobile/internal/domain/models"
+)
+
+// Section types.
+const (
+	SectionVerse     = "verse"
+	SectionChorus    = "chorus"
+	SectionPreChorus = "pre-chorus"
+	SectionBridge    = "bridge"
+	SectionIntro     = "intro"
+	SectionOutro     = "outro"
+	SectionOther     = "other"
+)
+
+var sectionTypes = map[string]struct{}{
+	SectionVerse:     {},
+	SectionChorus:    {},
+	SectionPreChorus: {},
+	SectionBridge:    {},
+	SectionIntro:     {},
+	SectionOutro:     {},
+	SectionOther:     {},
+}
+
+// markerAliases maps the normalized name of a marker to its section type.
+var markerAliases = map[string]string{
+	"verse":      SectionVerse,
+	"couplet":    SectionVerse,
+	"куплет":     SectionVerse,
+	"chorus":     SectionChorus,
+	"refrain":    SectionChorus,
+	"hook":       SectionChorus,
+	"припев":     SectionChorus,
+	"pre-chorus": SectionPreChorus,
+	"prechorus":  SectionPreChorus,
+	"pre chorus": SectionPreChorus,
+	"bridge":     SectionBridge,
+	"middle":     SectionBridge,
+	"бридж":      SectionBridge,
+	"intro":      SectionIntro,
+	"вступление": SectionIntro,
+	"outro":      SectionOutro,
+	"концовка":   SectionOutro,
+}
+
+var markerLine = regexp.MustCompile(`^\[([^\[\]]+)\]$`)
+
+// IsSectionType reports whether t is a known section type.
+func IsSectionType(t string) bool {
+	_, ok := sectionTypes[t]
+
+	return ok
+}
+
+type block struct {
+	marker *marker
+	lines  []string
+	// index holds the line numbers of lines in the parsed text.
+	index []int
+}
+
+type marker struct {
+	label   string
+	section string
+}
+
+// Parse splits lyrics into typed sections.
+//
+// Blocks are separated by blank lines or by marker lines such as [Chorus]
+// or [Verse 2: Artist]. A marker types the block that follows it; a marker
+// with no lines of its own repeats the last section of that type, as in
+// lyrics that write the chorus out once and then only mark it. Unmarked
+// blocks are choruses when their text matches a marked chorus or occurs
+// more than once, and verses otherwise.
+func Parse(text string) []models.Section {
+	sections, _ := Split(text)
+
+	return sections
+}
+
+// Split is Parse that also returns, for every section, the numbers of the
+// lines of text it consists of, counted from 0. A section repeated by a bare
+// marker gets the lines of the section it repeats. Callers that keep extra
+// data per line, like chords, use them to map sections back to it.
+func Split(text string) ([]models.Section, [][]int) {
+	blocks := splitBlocks(text)
+
+	seen := make(map[string]int, len(blocks))
+	choruses := make(map[string]struct{})
+
+	for _, b := range blocks {
+		if len(b.lines) == 0 {
+			continue
+		}
+
+		key := normalize(b.lines)
+		seen[key]++
+
+		if b.marker != nil && b.marker.section == SectionChorus {
+			choruses[key] = struct{}{}
+		}
+	}
+
+	sections := make([]models.Section, 0, len(blocks))
+	spans := make([][]int, 0, len(blocks))
+	counts := make(map[string]int)
+	last := make(map[string]block)
+
+	for _, b := range blocks {
+		var section models.Section
+
+		switch {
+		case b.marker != nil:
+			section.Type = b.marker.section
+			section.Label = b.marker.label
+
+			if len(b.lines) == 0 {
+				previous, ok := last[section.Type]
+				if !ok {
+					continue
+				}
+
+				b.lines, b.index = previous.lines, previous.index
+			}
+		default:
+			key := normalize(b.lines)
+			_, marked := choruses[key]
+
+			if marked || seen[key] > 1 {
+				section.Type = SectionChorus
+			} else {
+				section.Type = SectionVerse
+			}
+		}
+
+		counts[section.Type]++
+		section.Number = counts[section.Type]
+		section.Text = strings.Join(b.lines, "\n")
+		last[section.Type] = b
+
+		sections = append(sections, section)
+		spans = append(spans, b.index)
+	}
+
+	return sections, spans
+}
+
+// Verses returns the text of the verse sections only.
+func Verses(sections []models.Section) []string {
+	verses := []string{}
+	for _, s := range sections {
+		if s.Type == SectionVerse {
+			verses = append(verses, s.Text)
+		}
+	}
+
+	return verses
+}
+
+// Filter returns the sections of type sectionType.
+func Filter(sections []models.Section, sectionType string) []models.Section {
+	filtered := []models.Section{}
+	for _, s := range sections {
+		if s.Type == sectionType {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return filtered
+}
+
+func splitBlocks(text string) []block {
+	var blocks []block
+	var current block
+
+	flush := func() {
+		if len(current.lines) > 0 {
+			blocks = append(blocks, current)
+			current = block{}
+		}
+	}
+
+	for n, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if m, ok := parseMarker(trimmed); ok {
+			flush()
+
+			if current.marker != nil {
+				blocks = append(blocks, current)
+			}
+
+			current = block{marker: m}
+
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+
+			continue
+		}
+
+		current.lines = append(current.lines, line)
+		current.index = append(current.index, n)
+	}
+
+	flush()
+
+	if current.marker != nil {
+		blocks = append(blocks, current)
+	}
+
+	return blocks
+}
+
+// MarkerSection returns the section type a marker label such as "Verse 2"
+// or "Припев" stands for.
+func MarkerSection(label string) string {
+	m, ok := parseMarker("[" + label + "]")
+	if !ok {
+		return SectionOther
+	}
+
+	return m.section
+}
+
+func parseMarker(line string) (*marker, bool) {
+	match := markerLine.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+
+	label := strings.TrimSpace(match[1])
+
+	name, _, _ := strings.Cut(strings.ToLower(label), ":")
+	name = strings.TrimFunc(name, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsSpace(r) || r == '#'
+	})
+	name = strings.TrimSpace(strings.TrimSuffix(name, " x"))
+
+	section, ok := markerAliases[name]
+	if !ok {
+		section = SectionOther
+	}
+
+	return &marker{label: label, section: section}, true
+}
+
+// normalize makes the key repeated blocks are compared by: case, spacing
+// and punctuation differences do not make a chorus a different block.
+func normalize(lines []string) string {
+	var b strings.Builder
+
+	for _, line := range lines {
+		for _, r := range strings.ToLower(line) {
+			switch {
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				b.WriteRune(r)
+			case unicode.IsSpace(r):
+				b.WriteByte(' ')
+			}
+		}
+
+		b.WriteByte('\n')
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,149 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+
+	"effective_mobile/internal/domain/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []models.Section
+	}{
+		{
+			name: "unmarked verses",
+			text: "First line\nSecond line\n\nThird line",
+			want: []models.Section{
+				{Type: SectionVerse, Number: 1, Text: "First line\nSecond line"},
+				{Type: SectionVerse, Number: 2, Text: "Third line"},
+			},
+		},
+		{
+			name: "unmarked repeated block is a chorus",
+			text: "Verse one\n\nLa la la\n\nVerse two\n\nLa, la, LA!",
+			want: []models.Section{
+				{Type: SectionVerse, Number: 1, Text: "Verse one"},
+				{Type: SectionChorus, Number: 1, Text: "La la la"},
+				{Type: SectionVerse, Number: 2, Text: "Verse two"},
+				{Type: SectionChorus, Number: 2, Text: "La, la, LA!"},
+			},
+		},
+		{
+			name: "markers type the blocks that follow",
+			text: "[Intro]\nOh\n[Verse 1: Artist]\nVerse one\n[Pre-Chorus]\nGetting there\n[Chorus]\nLa la la\n[Bridge]\nOver\n[Outro]\nBye\n[Skit]\nTalking",
+			want: []models.Section{
+				{Type: SectionIntro, Number: 1, Label: "Intro", Text: "Oh"},
+				{Type: SectionVerse, Number: 1, Label: "Verse 1: Artist", Text: "Verse one"},
+				{Type: SectionPreChorus, Number: 1, Label: "Pre-Chorus", Text: "Getting there"},
+				{Type: SectionChorus, Number: 1, Label: "Chorus", Text: "La la la"},
+				{Type: SectionBridge, Number: 1, Label: "Bridge", Text: "Over"},
+				{Type: SectionOutro, Number: 1, Label: "Outro", Text: "Bye"},
+				{Type: SectionOther, Number: 1, Label: "Skit", Text: "Talking"},
+			},
+		},
+		{
+			name: "bare marker repeats the last chorus",
+			text: "[Куплет 1]\nПервый\n\n[Припев]\nЛа-ла\n\n[Куплет 2]\nВторой\n\n[Припев]\n\n[Chorus x2]",
+			want: []models.Section{
+				{Type: SectionVerse, Number: 1, Label: "Куплет 1", Text: "Первый"},
+				{Type: SectionChorus, Number: 1, Label: "Припев", Text: "Ла-ла"},
+				{Type: SectionVerse, Number: 2, Label: "Куплет 2", Text: "Второй"},
+				{Type: SectionChorus, Number: 2, Label: "Припев", Text: "Ла-ла"},
+				{Type: SectionChorus, Number: 3, Label: "Chorus x2", Text: "Ла-ла"},
+			},
+		},
+		{
+			name: "bare marker without a section to repeat is dropped",
+			text: "[Chorus]\n\n[Verse]\nOnly verse",
+			want: []models.Section{
+				{Type: SectionVerse, Number: 1, Label: "Verse", Text: "Only verse"},
+			},
+		},
+		{
+			name: "unmarked block matching a marked chorus",
+			text: "[Chorus]\nLa la la\n\nVerse one\n\nLa la la",
+			want: []models.Section{
+				{Type: SectionChorus, Number: 1, Label: "Chorus", Text: "La la la"},
+				{Type: SectionVerse, Number: 1, Text: "Verse one"},
+				{Type: SectionChorus, Number: 2, Text: "La la la"},
+			},
+		},
+		{
+			name: "empty lyrics",
+			text: "\n\n",
+			want: []models.Section{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := "[Verse]\nFirst\nSecond\n\n[Chorus]\nLa la\n\n[Verse]\nThird\n\n[Chorus]"
+
+	sections, spans := Split(text)
+
+	wantTypes := []string{SectionVerse, SectionChorus, SectionVerse, SectionChorus}
+	wantSpans := [][]int{{1, 2}, {5}, {8}, {5}}
+
+	if len(sections) != len(wantTypes) {
+		t.Fatalf("Split() returned %d sections, want %d", len(sections), len(wantTypes))
+	}
+
+	for i, section := range sections {
+		if section.Type != wantTypes[i] {
+			t.Errorf("section %d type = %q, want %q", i, section.Type, wantTypes[i])
+		}
+	}
+
+	if !reflect.DeepEqual(spans, wantSpans) {
+		t.Errorf("Split() spans = %v, want %v", spans, wantSpans)
+	}
+}
+
+func TestVersesAndFilter(t *testing.T) {
+	sections := Parse("Verse one\n\nLa la\n\nVerse two\n\nLa la")
+
+	if got, want := Verses(sections), []string{"Verse one", "Verse two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Verses() = %v, want %v", got, want)
+	}
+
+	if got := Filter(sections, SectionChorus); len(got) != 2 || got[1].Number != 2 {
+		t.Errorf("Filter(chorus) = %+v, want two numbered choruses", got)
+	}
+
+	if got := Filter(sections, SectionBridge); len(got) != 0 {
+		t.Errorf("Filter(bridge) = %+v, want none", got)
+	}
+}
+
+func TestMarkerSection(t *testing.T) {
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"Verse 2", SectionVerse},
+		{"Chorus x2", SectionChorus},
+		{"Hook: Artist", SectionChorus},
+		{"Pre chorus", SectionPreChorus},
+		{"Припев", SectionChorus},
+		{"Interlude", SectionOther},
+	}
+
+	for _, tt := range tests {
+		if got := MarkerSection(tt.label); got != tt.want {
+			t.Errorf("MarkerSection(%q) = %q, want %q", tt.label, got, tt.want)
+		}
+	}
+}
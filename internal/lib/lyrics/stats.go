@@ -0,0 +1,46 @@
+package lyrics
+
+import (
+	"math"
+	"strings"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/wordstats"
+)
+
+// Stats counts the lines and words of lyrics in language lang as they are
+// sung: a chorus repeated by a bare marker counts every time. TopWords are
+// the top most frequent words that are not stop words of lang.
+func Stats(text, lang string, top int) models.LyricsStats {
+	stats := models.LyricsStats{Lang: lang}
+
+	var words []string
+	var chorusLines int
+
+	for _, section := range Parse(text) {
+		lines := strings.Split(section.Text, "\n")
+
+		stats.Lines += len(lines)
+		words = append(words, wordstats.Words(section.Text)...)
+
+		switch section.Type {
+		case SectionVerse:
+			stats.Verses++
+		case SectionChorus:
+			stats.Choruses++
+			chorusLines += len(lines)
+		}
+	}
+
+	stats.Words = len(words)
+	stats.UniqueWords = wordstats.Unique(words)
+	stats.ReadingSeconds = wordstats.Seconds(stats.Words, wordstats.ReadingWPM)
+	stats.SingingSeconds = wordstats.Seconds(stats.Words, wordstats.SingingWPM)
+	stats.TopWords = wordstats.Top(words, wordstats.StopWords(lang), top)
+
+	if stats.Lines > 0 {
+		stats.ChorusRatio = math.Round(float64(chorusLines)/float64(stats.Lines)*1000) / 1000
+	}
+
+	return stats
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "songs"
+
+// Outcomes of a call to the external API, by status class.
+const (
+	Outcome2xx   = "2xx"
+	Outcome3xx   = "3xx"
+	Outcome4xx   = "4xx"
+	Outcome5xx   = "5xx"
+	OutcomeError = "error"
+)
+
+// Metrics owns the service registry. Collectors are registered on a private
+// registry rather than the global one so only what is listed here is exposed.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+
+	externalRequests *prometheus.CounterVec
+	externalDuration *prometheus.HistogramVec
+
+	songs *prometheus.CounterVec
+}
+
+// New creates the service metrics and the pool gauges of db.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		externalRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "external_api",
+			Name:      "requests_total",
+			Help:      "Calls to the external music API by outcome.",
+		}, []string{"outcome"}),
+		externalDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "external_api",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of calls to the external music API by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+		songs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "library",
+			Name:      "changes_total",
+			Help:      "Songs created, updated and deleted.",
+		}, []string{"operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(db, "postgres"),
+		m.requests,
+		m.duration,
+		m.inFlight,
+		m.externalRequests,
+		m.externalDuration,
+		m.songs,
+	)
+
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) RequestStarted() {
+	m.inFlight.Inc()
+}
+
+// RequestFinished records a served request. route is the chi route pattern,
+// not the path, to keep the label cardinality bounded.
+func (m *Metrics) RequestFinished(method, route string, status int, duration time.Duration) {
+	m.inFlight.Dec()
+
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ExternalCall records a call to the external API; status is 0 when no
+// response was received.
+func (m *Metrics) ExternalCall(status int, duration time.Duration) {
+	outcome := StatusClass(status)
+
+	m.externalRequests.WithLabelValues(outcome).Inc()
+	m.externalDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+func (m *Metrics) SongCreated() {
+	m.songs.WithLabelValues("created").Inc()
+}
+
+func (m *Metrics) SongUpdated() {
+	m.songs.WithLabelValues("updated").Inc()
+}
+
+func (m *Metrics) SongDeleted() {
+	m.songs.WithLabelValues("deleted").Inc()
+}
+
+// StatusClass maps an HTTP status to its outcome label.
+func StatusClass(status int) string {
+	switch {
+	case status >= 200 && status < 300:
+		return Outcome2xx
+	case status >= 300 && status < 400:
+		return Outcome3xx
+	case status >= 400 && status < 500:
+		return Outcome4xx
+	case status >= 500 && status < 600:
+		return Outcome5xx
+	default:
+		return OutcomeError
+	}
+}
@@ -0,0 +1,19 @@
+package principal
+
+import (
+	"context"
+
+	"effective_mobile/internal/domain/models"
+)
+
+type ctxKey struct{}
+
+func With(ctx context.Context, p models.Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+func FromContext(ctx context.Context) (models.Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(models.Principal)
+
+	return p, ok
+}
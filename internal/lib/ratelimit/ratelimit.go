@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result describes the state of a bucket after a request was counted.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a set of token buckets keyed by client. Each bucket holds up to
+// burst tokens and refills at rate tokens per second, which must be
+// positive; with a rate of 0 a bucket is never refilled and no retry time
+// is known. Buckets unused for idleTTL are dropped; with an idleTTL of 0 or
+// less they are kept.
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   int
+	idleTTL time.Duration
+	buckets map[string]*bucket
+	now     func() time.Time
+	stop    chan struct{}
+	once    sync.Once
+}
+
+func New(rate float64, burst int, idleTTL time.Duration) *Limiter {
+	l := &Limiter{
+		rate:    rate,
+		burst:   burst,
+		idleTTL: idleTTL,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	if idleTTL > 0 {
+		go l.evictIdle()
+	}
+
+	return l
+}
+
+// Stop stops dropping idle buckets. The limiter keeps counting requests.
+func (l *Limiter) Stop() {
+	l.once.Do(func() { close(l.stop) })
+}
+
+// Allow takes a token from the bucket of key if one is available.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	res := Result{Limit: l.burst}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.duration(1 - b.tokens)
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = l.duration(float64(l.burst) - b.tokens)
+
+	return res
+}
+
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+func (l *Limiter) evictIdle() {
+	ticker := time.NewTicker(l.idleTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			for key, b := range l.buckets {
+				if l.now().Sub(b.last) > l.idleTTL {
+					delete(l.buckets, key)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// clock is a time source for tests that only moves when told to.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *clock) {
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	l := New(rate, burst, 0)
+	l.now = c.Now
+
+	return l, c
+}
+
+func TestAllowBurst(t *testing.T) {
+	l, _ := newTestLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		res := l.Allow("a")
+		if !res.Allowed {
+			t.Fatalf("request %d was rejected within the burst", i+1)
+		}
+
+		if res.Limit != 3 || res.Remaining != 2-i {
+			t.Errorf("request %d: limit, remaining = %d, %d, want 3, %d", i+1, res.Limit, res.Remaining, 2-i)
+		}
+	}
+
+	res := l.Allow("a")
+	if res.Allowed {
+		t.Fatal("request over the burst was allowed")
+	}
+
+	if res.Remaining != 0 || res.RetryAfter != time.Second || res.Reset != 3*time.Second {
+		t.Errorf("rejected result = %+v, want 0 remaining, retry after 1s, reset in 3s", res)
+	}
+
+	if !l.Allow("b").Allowed {
+		t.Error("another client was limited by the bucket of the first")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l, c := newTestLimiter(2, 2)
+
+	l.Allow("a")
+	l.Allow("a")
+
+	c.Advance(250 * time.Millisecond)
+
+	res := l.Allow("a")
+	if res.Allowed {
+		t.Fatal("request was allowed with half a token")
+	}
+
+	if res.RetryAfter != 250*time.Millisecond {
+		t.Errorf("retry after = %v, want 250ms", res.RetryAfter)
+	}
+
+	c.Advance(250 * time.Millisecond)
+
+	if !l.Allow("a").Allowed {
+		t.Fatal("request was rejected after a token was refilled")
+	}
+
+	// A bucket does not grow past its burst however long it is left.
+	c.Advance(time.Hour)
+
+	if res := l.Allow("a"); res.Remaining != 1 || res.Reset != 500*time.Millisecond {
+		t.Errorf("after an hour remaining, reset = %d, %v, want 1, 500ms", res.Remaining, res.Reset)
+	}
+}
+
+func TestAllowWithoutRate(t *testing.T) {
+	l, c := newTestLimiter(0, 1)
+
+	l.Allow("a")
+	c.Advance(time.Hour)
+
+	res := l.Allow("a")
+	if res.Allowed || res.RetryAfter != 0 {
+		t.Errorf("result = %+v, want rejected without a retry time", res)
+	}
+}
+
+func TestEvictIdle(t *testing.T) {
+	const idleTTL = 20 * time.Millisecond
+
+	l := New(1, 1, idleTTL)
+	defer l.Stop()
+
+	l.Allow("a")
+
+	deadline := time.Now().Add(time.Second)
+	for buckets(l) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("idle bucket was not evicted")
+		}
+
+		time.Sleep(idleTTL / 2)
+	}
+}
+
+func TestStop(t *testing.T) {
+	const idleTTL = 10 * time.Millisecond
+
+	l := New(1, 1, idleTTL)
+	l.Stop()
+	l.Stop()
+
+	// Let a tick that raced Stop finish.
+	time.Sleep(2 * idleTTL)
+
+	l.Allow("a")
+	time.Sleep(5 * idleTTL)
+
+	if buckets(l) != 1 {
+		t.Error("buckets were evicted after Stop")
+	}
+
+	if !l.Allow("b").Allowed {
+		t.Error("stopped limiter does not count requests")
+	}
+}
+
+func TestStopWithoutEviction(t *testing.T) {
+	l := New(1, 1, 0)
+	l.Stop()
+
+	l.Allow("a")
+	if buckets(l) != 1 {
+		t.Error("bucket was dropped with eviction disabled")
+	}
+}
+
+func buckets(l *Limiter) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
@@ -0,0 +1,134 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"effective_mobile/internal/domain/models"
+)
+
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+type Permission string
+
+const (
+	PermSongsRead           Permission = "songs:read"
+	PermSongsCreate         Permission = "songs:create"
+	PermSongsUpdate         Permission = "songs:update"
+	PermSongsUpdateIdentity Permission = "songs:update:identity"
+	PermSongsDelete         Permission = "songs:delete"
+	PermSongsExport         Permission = "songs:export"
+	PermAPIKeysManage       Permission = "apikeys:manage"
+	PermLogsManage          Permission = "logs:manage"
+)
+
+const (
+	ScopeSongsRead   = "songs:read"
+	ScopeSongsWrite  = "songs:write"
+	ScopeSongsDelete = "songs:delete"
+	ScopeExport      = "export"
+)
+
+// scopes lists the permissions granted by each API key scope.
+var scopes = map[string][]Permission{
+	ScopeSongsRead:   {PermSongsRead},
+	ScopeSongsWrite:  {PermSongsCreate, PermSongsUpdate, PermSongsUpdateIdentity},
+	ScopeSongsDelete: {PermSongsDelete},
+	ScopeExport:      {PermSongsExport},
+}
+
+// IsScope reports whether scope is a known API key scope.
+func IsScope(scope string) bool {
+	_, ok := scopes[scope]
+
+	return ok
+}
+
+// Policy maps roles to the permissions they grant.
+type Policy struct {
+	roles map[string]map[Permission]struct{}
+}
+
+func NewPolicy(roles map[string][]Permission) *Policy {
+	p := &Policy{roles: make(map[string]map[Permission]struct{}, len(roles))}
+
+	for role, perms := range roles {
+		set := make(map[Permission]struct{}, len(perms))
+		for _, perm := range perms {
+			set[perm] = struct{}{}
+		}
+
+		p.roles[role] = set
+	}
+
+	return p
+}
+
+// DefaultPolicy lets viewers read, editors create, update everything but
+// the group and song name and export, and admins do anything.
+func DefaultPolicy() *Policy {
+	return NewPolicy(map[string][]Permission{
+		RoleViewer: {PermSongsRead},
+		RoleEditor: {PermSongsRead, PermSongsCreate, PermSongsUpdate, PermSongsExport},
+		RoleAdmin: {
+			PermSongsRead, PermSongsCreate, PermSongsUpdate, PermSongsUpdateIdentity, PermSongsDelete,
+			PermSongsExport, PermAPIKeysManage, PermLogsManage,
+		},
+	})
+}
+
+// LoadPolicy reads a JSON object of role names to permission lists,
+// e.g. {"viewer": ["songs:read"]}. An empty path yields DefaultPolicy.
+func LoadPolicy(path string) (*Policy, error) {
+	const op = "lib.rbac.LoadPolicy"
+
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var roles map[string][]Permission
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return NewPolicy(roles), nil
+}
+
+// Allowed reports whether any of roles grants perm.
+func (p *Policy) Allowed(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		if _, ok := p.roles[role][perm]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Permits reports whether principal may perm. API key principals are
+// checked against their scopes, everyone else against their roles.
+func (p *Policy) Permits(principal models.Principal, perm Permission) bool {
+	if principal.Method != models.AuthMethodAPIKey {
+		return p.Allowed(principal.Roles, perm)
+	}
+
+	for _, scope := range principal.Scopes {
+		for _, granted := range scopes[scope] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+
+	return false
+}
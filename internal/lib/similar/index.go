@@ -0,0 +1,446 @@
+// Package similar ranks songs by how similar they are to a song: by the
+// TF-IDF cosine similarity of their lyrics, a shared artist and how close
+// their release years are. Songs have no tags, so shared tags are not a
+// signal; one would be added here, as another weighted reason, along with
+// them.
+package similar
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/dedup"
+	"effective_mobile/internal/lib/wordstats"
+)
+
+// maxReasonWords is how many shared words the lyrics reason lists at most.
+const maxReasonWords = 5
+
+// Weights of the signals songs are ranked by. The score of a song is the
+// weighted mean of its signals, so only the ratios between weights matter.
+type Weights struct {
+	Lyrics float64
+	Artist float64
+	Year   float64
+	// YearWindow is how many years apart releases still count as close.
+	YearWindow int
+}
+
+// LoadFunc returns the songs with IDs greater than afterID, in ID order. An
+// empty result ends the load.
+type LoadFunc func(ctx context.Context, afterID int) ([]models.SongData, error)
+
+type doc struct {
+	ref    models.SongRef
+	artist string
+	year   int
+	terms  map[string]int
+}
+
+// tables are the documents of the index and the lookups of their terms and
+// artists.
+type tables struct {
+	docs     map[int]*doc
+	postings map[string]map[int]struct{}
+	artists  map[string]map[int]struct{}
+}
+
+// change is a song put into the index, or removed when song is nil.
+type change struct {
+	id   int
+	song *models.SongData
+}
+
+// Index is an in-memory index of the songs of the library. Songs are put
+// and removed as they change; Rebuild loads all of them again, which also
+// picks up the changes made by other instances.
+type Index struct {
+	weights Weights
+
+	mu         sync.RWMutex
+	tables     *tables
+	ready      bool
+	rebuilding bool
+	pending    []change
+}
+
+func New(weights Weights) *Index {
+	return &Index{
+		weights: weights,
+		tables:  newTables(),
+	}
+}
+
+// Ready reports whether the index was loaded.
+func (ix *Index) Ready() bool {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return ix.ready
+}
+
+// Put adds song to the index or replaces it.
+func (ix *Index) Put(song models.SongData) {
+	d := newDoc(song)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.rebuilding {
+		ix.pending = append(ix.pending, change{id: song.ID, song: &song})
+	}
+
+	ix.tables.put(song.ID, d)
+}
+
+// Remove removes song id from the index.
+func (ix *Index) Remove(id int) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if ix.rebuilding {
+		ix.pending = append(ix.pending, change{id: id})
+	}
+
+	ix.tables.remove(id)
+}
+
+// Rebuild loads every song with load and replaces the index with them.
+// Songs put or removed while it loads are kept as they were changed.
+func (ix *Index) Rebuild(ctx context.Context, load LoadFunc) error {
+	ix.mu.Lock()
+	ix.rebuilding = true
+	ix.pending = nil
+	ix.mu.Unlock()
+
+	fresh := newTables()
+
+	var afterID int
+	for {
+		songs, err := load(ctx, afterID)
+		if err != nil {
+			ix.mu.Lock()
+			ix.rebuilding = false
+			ix.pending = nil
+			ix.mu.Unlock()
+
+			return err
+		}
+
+		if len(songs) == 0 {
+			break
+		}
+
+		for _, song := range songs {
+			fresh.put(song.ID, newDoc(song))
+		}
+
+		afterID = songs[len(songs)-1].ID
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for _, c := range ix.pending {
+		if c.song == nil {
+			fresh.remove(c.id)
+		} else {
+			fresh.put(c.id, newDoc(*c.song))
+		}
+	}
+
+	ix.tables = fresh
+	ix.ready = true
+	ix.rebuilding = false
+	ix.pending = nil
+
+	return nil
+}
+
+// Similar returns up to limit songs most similar to song id, best first.
+// Only songs that share words of the lyrics or the artist with it are
+// ranked. It reports false when song id is not in the index.
+func (ix *Index) Similar(id, limit int) ([]models.SimilarSong, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	t := ix.tables
+
+	song, ok := t.docs[id]
+	if !ok {
+		return nil, false
+	}
+
+	idf := func(term string) float64 {
+		return math.Log(1 + float64(len(t.docs))/float64(len(t.postings[term])))
+	}
+
+	weights := make(map[string]float64, len(song.terms))
+	var norm float64
+	for term, count := range song.terms {
+		w := termWeight(count) * idf(term)
+		weights[term] = w
+		norm += w * w
+	}
+	norm = math.Sqrt(norm)
+
+	dots := make(map[int]float64)
+	for term, w := range weights {
+		for other := range t.postings[term] {
+			if other != id {
+				dots[other] += w * termWeight(t.docs[other].terms[term]) * idf(term)
+			}
+		}
+	}
+
+	if song.artist != "" {
+		for other := range t.artists[song.artist] {
+			if _, ok := dots[other]; !ok && other != id {
+				dots[other] = 0
+			}
+		}
+	}
+
+	total := ix.weights.Lyrics + ix.weights.Artist + ix.weights.Year
+	if total <= 0 {
+		return []models.SimilarSong{}, true
+	}
+
+	results := make([]models.SimilarSong, 0, len(dots))
+	for other, dot := range dots {
+		candidate := t.docs[other]
+
+		var reasons []models.SimilarityReason
+
+		if dot > 0 {
+			var otherNorm float64
+			for term, count := range candidate.terms {
+				w := termWeight(count) * idf(term)
+				otherNorm += w * w
+			}
+
+			cosine := dot / (norm * math.Sqrt(otherNorm))
+
+			reasons = appendReason(reasons, ix.weights.Lyrics*cosine/total, models.SimilarityReason{
+				Signal: models.SignalLyrics,
+				Detail: fmt.Sprintf("lyrics are %.0f%% similar", cosine*100),
+				Words:  sharedWords(weights, candidate.terms),
+			})
+		}
+
+		if song.artist != "" && song.artist == candidate.artist {
+			reasons = appendReason(reasons, ix.weights.Artist/total, models.SimilarityReason{
+				Signal: models.SignalArtist,
+				Detail: "also by " + candidate.ref.Group,
+			})
+		}
+
+		if proximity, apart := ix.yearProximity(song.year, candidate.year); proximity > 0 {
+			detail := "released the same year"
+			if apart == 1 {
+				detail = "released a year apart"
+			} else if apart > 1 {
+				detail = fmt.Sprintf("released %d years apart", apart)
+			}
+
+			reasons = appendReason(reasons, ix.weights.Year*proximity/total, models.SimilarityReason{
+				Signal: models.SignalYear,
+				Detail: detail,
+			})
+		}
+
+		var score float64
+		for _, r := range reasons {
+			score += r.Score
+		}
+
+		if score == 0 {
+			continue
+		}
+
+		sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].Score > reasons[j].Score })
+
+		results = append(results, models.SimilarSong{
+			Song:    candidate.ref,
+			Score:   round(score),
+			Reasons: reasons,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].Song.ID < results[j].Song.ID
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, true
+}
+
+// yearProximity returns how close years a and b are, from 1 for the same
+// year to 0 from the year window on, and how many years they are apart.
+// Unknown years are not close to any.
+func (ix *Index) yearProximity(a, b int) (float64, int) {
+	if a == 0 || b == 0 || ix.weights.YearWindow <= 0 {
+		return 0, 0
+	}
+
+	apart := a - b
+	if apart < 0 {
+		apart = -apart
+	}
+
+	return math.Max(0, 1-float64(apart)/float64(ix.weights.YearWindow)), apart
+}
+
+// appendReason appends reason with score unless it adds nothing.
+func appendReason(reasons []models.SimilarityReason, score float64, reason models.SimilarityReason) []models.SimilarityReason {
+	if score <= 0 {
+		return reasons
+	}
+
+	reason.Score = round(score)
+
+	return append(reasons, reason)
+}
+
+// sharedWords returns the words of terms that weigh most in weights.
+func sharedWords(weights map[string]float64, terms map[string]int) []string {
+	words := make([]string, 0)
+	for term := range terms {
+		if _, ok := weights[term]; ok {
+			words = append(words, term)
+		}
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		wi := weights[words[i]] * termWeight(terms[words[i]])
+		wj := weights[words[j]] * termWeight(terms[words[j]])
+		if wi != wj {
+			return wi > wj
+		}
+
+		return words[i] < words[j]
+	})
+
+	if len(words) > maxReasonWords {
+		words = words[:maxReasonWords]
+	}
+
+	return words
+}
+
+// termWeight dampens the count of a term, so a chorus sung ten times does
+// not outweigh the rest of the lyrics.
+func termWeight(count int) float64 {
+	return 1 + math.Log(float64(count))
+}
+
+func round(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
+
+func newDoc(song models.SongData) *doc {
+	stop := wordstats.StopWords(song.Language)
+
+	terms := make(map[string]int)
+	for _, w := range wordstats.Words(song.Text) {
+		if _, ok := stop[w]; ok || utf8.RuneCountInString(w) < 2 {
+			continue
+		}
+
+		terms[w]++
+	}
+
+	return &doc{
+		ref:    models.SongRef{ID: song.ID, Group: song.Group, Song: song.Song},
+		artist: dedup.GroupKey(song.Group),
+		year:   releaseYear(song.ReleaseDate),
+		terms:  terms,
+	}
+}
+
+// releaseYear returns the year of a release date stored as YYYY-MM-DD or
+// an RFC 3339 time, or 0 when it is unknown.
+func releaseYear(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+
+	return year
+}
+
+func newTables() *tables {
+	return &tables{
+		docs:     make(map[int]*doc),
+		postings: make(map[string]map[int]struct{}),
+		artists:  make(map[string]map[int]struct{}),
+	}
+}
+
+func (t *tables) put(id int, d *doc) {
+	t.remove(id)
+
+	t.docs[id] = d
+
+	for term := range d.terms {
+		add(t.postings, term, id)
+	}
+
+	if d.artist != "" {
+		add(t.artists, d.artist, id)
+	}
+}
+
+func (t *tables) remove(id int) {
+	d, ok := t.docs[id]
+	if !ok {
+		return
+	}
+
+	delete(t.docs, id)
+
+	for term := range d.terms {
+		drop(t.postings, term, id)
+	}
+
+	drop(t.artists, d.artist, id)
+}
+
+func add(index map[string]map[int]struct{}, key string, id int) {
+	ids, ok := index[key]
+	if !ok {
+		ids = make(map[int]struct{})
+		index[key] = ids
+	}
+
+	ids[id] = struct{}{}
+}
+
+func drop(index map[string]map[int]struct{}, key string, id int) {
+	ids, ok := index[key]
+	if !ok {
+		return
+	}
+
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,298 @@
+package similar
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"effective_mobile/internal/domain/models"
+)
+
+var testWeights = Weights{Lyrics: 0.6, Artist: 0.3, Year: 0.1, YearWindow: 10}
+
+var library = []models.SongData{
+	{ID: 1, Group: "Muse", Song: "Stone River", ReleaseDate: "2006-07-03", Text: "river stone moonlight river\nstone silver moonlight"},
+	{ID: 2, Group: "Radiohead", Song: "Silver River", ReleaseDate: "2006-01-01", Text: "river stone moonlight\nsilver moonlight river"},
+	{ID: 3, Group: "MUSE", Song: "Uprising", ReleaseDate: "2009-09-07", Text: "paranoia blooming transmissions"},
+	{ID: 4, Group: "Queen", Song: "Other", ReleaseDate: "1975-10-31", Text: "galileo figaro magnifico"},
+	{ID: 5, Group: "Blur", Song: "River Song", Text: "river flows past"},
+}
+
+// load returns songs in batches of two, as the storage pages them.
+func load(songs []models.SongData) LoadFunc {
+	return func(_ context.Context, afterID int) ([]models.SongData, error) {
+		var batch []models.SongData
+		for _, song := range songs {
+			if song.ID > afterID && len(batch) < 2 {
+				batch = append(batch, song)
+			}
+		}
+
+		return batch, nil
+	}
+}
+
+func rebuilt(t *testing.T) *Index {
+	t.Helper()
+
+	ix := New(testWeights)
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	return ix
+}
+
+func ids(songs []models.SimilarSong) []int {
+	ids := make([]int, len(songs))
+	for i, s := range songs {
+		ids[i] = s.Song.ID
+	}
+
+	return ids
+}
+
+func signals(reasons []models.SimilarityReason) []string {
+	signals := make([]string, len(reasons))
+	for i, r := range reasons {
+		signals[i] = r.Signal
+	}
+
+	return signals
+}
+
+func TestReady(t *testing.T) {
+	ix := New(testWeights)
+
+	if ix.Ready() {
+		t.Error("index is ready before it was loaded")
+	}
+
+	if _, ok := ix.Similar(1, 10); ok {
+		t.Error("Similar() found a song in an empty index")
+	}
+
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if !ix.Ready() {
+		t.Error("index is not ready after Rebuild")
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	ix := rebuilt(t)
+
+	got, ok := ix.Similar(1, 10)
+	if !ok {
+		t.Fatal("Similar() did not find song 1")
+	}
+
+	// Song 4 shares neither words nor the artist.
+	if want := []int{2, 3, 5}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("Similar() = %v, want %v", ids(got), want)
+	}
+
+	for i := 1; i < len(got); i++ {
+		if got[i].Score > got[i-1].Score {
+			t.Errorf("results are not sorted by score: %v", got)
+		}
+	}
+
+	lyricsMatch := got[0]
+	if want := []string{models.SignalLyrics, models.SignalYear}; !reflect.DeepEqual(signals(lyricsMatch.Reasons), want) {
+		t.Errorf("reasons of song 2 = %v, want %v", signals(lyricsMatch.Reasons), want)
+	}
+
+	if words := lyricsMatch.Reasons[0].Words; len(words) == 0 || len(words) > maxReasonWords {
+		t.Errorf("shared words of song 2 = %v", words)
+	}
+
+	if detail := lyricsMatch.Reasons[1].Detail; detail != "released the same year" {
+		t.Errorf("year reason of song 2 = %q", detail)
+	}
+
+	sameArtist := got[1]
+	if want := []string{models.SignalArtist, models.SignalYear}; !reflect.DeepEqual(signals(sameArtist.Reasons), want) {
+		t.Errorf("reasons of song 3 = %v, want %v", signals(sameArtist.Reasons), want)
+	}
+
+	if detail := sameArtist.Reasons[0].Detail; detail != "also by MUSE" {
+		t.Errorf("artist reason of song 3 = %q", detail)
+	}
+
+	if detail := sameArtist.Reasons[1].Detail; detail != "released 3 years apart" {
+		t.Errorf("year reason of song 3 = %q", detail)
+	}
+
+	// Song 5 has no release date, so only its lyrics count.
+	if want := []string{models.SignalLyrics}; !reflect.DeepEqual(signals(got[2].Reasons), want) {
+		t.Errorf("reasons of song 5 = %v, want %v", signals(got[2].Reasons), want)
+	}
+
+	var sum float64
+	for _, r := range lyricsMatch.Reasons {
+		sum += r.Score
+	}
+
+	if diff := sum - lyricsMatch.Score; diff > 0.002 || diff < -0.002 {
+		t.Errorf("score of song 2 = %v, want the sum of its reasons %v", lyricsMatch.Score, sum)
+	}
+}
+
+func TestSimilarLimit(t *testing.T) {
+	ix := rebuilt(t)
+
+	got, _ := ix.Similar(1, 1)
+	if want := []int{2}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Similar() = %v, want %v", ids(got), want)
+	}
+}
+
+func TestSimilarWeights(t *testing.T) {
+	ix := New(Weights{Artist: 1})
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	got, _ := ix.Similar(1, 10)
+	if want := []int{3}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Similar() with the artist only = %v, want %v", ids(got), want)
+	}
+
+	ix = New(Weights{})
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if got, ok := ix.Similar(1, 10); !ok || len(got) != 0 {
+		t.Errorf("Similar() without weights = %v, %t, want none", ids(got), ok)
+	}
+}
+
+func TestPutAndRemove(t *testing.T) {
+	ix := rebuilt(t)
+
+	ix.Put(models.SongData{ID: 6, Group: "Queen", Song: "Galileo", Text: "galileo figaro"})
+
+	if got, _ := ix.Similar(4, 10); !reflect.DeepEqual(ids(got), []int{6}) {
+		t.Errorf("Similar() after Put = %v, want [6]", ids(got))
+	}
+
+	// Putting a song again replaces its words.
+	ix.Put(models.SongData{ID: 6, Group: "Blur", Song: "Galileo", Text: "nothing alike"})
+
+	if got, _ := ix.Similar(4, 10); len(got) != 0 {
+		t.Errorf("Similar() after replacing song 6 = %v, want none", ids(got))
+	}
+
+	ix.Remove(2)
+
+	if _, ok := ix.Similar(2, 10); ok {
+		t.Error("Similar() found a removed song")
+	}
+
+	if got, _ := ix.Similar(1, 10); !reflect.DeepEqual(ids(got), []int{3, 5}) {
+		t.Errorf("Similar() after Remove = %v, want [3 5]", ids(got))
+	}
+}
+
+func TestRebuildKeepsChangesMadeWhileLoading(t *testing.T) {
+	ix := rebuilt(t)
+
+	stale := append([]models.SongData(nil), library...)
+	stale[4].Text = "unrelated words"
+
+	loadAndChange := func(ctx context.Context, afterID int) ([]models.SongData, error) {
+		if afterID == 2 {
+			// Changes saved after their songs were loaded.
+			ix.Remove(2)
+			ix.Put(models.SongData{ID: 5, Group: "Blur", Song: "River Song", Text: "river stone moonlight"})
+			ix.Put(models.SongData{ID: 7, Group: "Oasis", Song: "Moonlight", Text: "silver moonlight stone"})
+		}
+
+		return load(stale)(ctx, afterID)
+	}
+
+	if err := ix.Rebuild(context.Background(), loadAndChange); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if _, ok := ix.Similar(2, 10); ok {
+		t.Error("song removed while loading is back in the index")
+	}
+
+	got, _ := ix.Similar(1, 10)
+	if want := []int{5, 7, 3}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("Similar() = %v, want %v", ids(got), want)
+	}
+
+	// Changes after the rebuild are not replayed by the next one.
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if _, ok := ix.Similar(7, 10); ok {
+		t.Error("song put during an earlier rebuild survived a later one")
+	}
+}
+
+func TestRebuildError(t *testing.T) {
+	ix := rebuilt(t)
+
+	failing := func(_ context.Context, afterID int) ([]models.SongData, error) {
+		if afterID > 0 {
+			return nil, errors.New("connection refused")
+		}
+
+		ix.Put(models.SongData{ID: 8, Group: "Muse", Song: "New", Text: "river"})
+
+		return library[:2], nil
+	}
+
+	if err := ix.Rebuild(context.Background(), failing); err == nil {
+		t.Fatal("Rebuild() error = nil")
+	}
+
+	if !ix.Ready() {
+		t.Error("failed rebuild made the index not ready")
+	}
+
+	// The old tables stay, with the change made meanwhile.
+	for _, id := range []int{4, 8} {
+		if _, ok := ix.Similar(id, 10); !ok {
+			t.Errorf("song %d is missing after a failed rebuild", id)
+		}
+	}
+
+	ix.Remove(8)
+
+	if err := ix.Rebuild(context.Background(), load(library)); err != nil {
+		t.Fatalf("Rebuild() error = %v", err)
+	}
+
+	if _, ok := ix.Similar(8, 10); ok {
+		t.Error("change pending from a failed rebuild was replayed")
+	}
+}
+
+func TestReleaseYear(t *testing.T) {
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"2006-07-03", 2006},
+		{"2006-07-03T00:00:00Z", 2006},
+		{"", 0},
+		{"06", 0},
+		{"unknown", 0},
+	}
+
+	for _, tt := range tests {
+		if got := releaseYear(tt.date); got != tt.want {
+			t.Errorf("releaseYear(%q) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package suggest caches typeahead suggestions in memory.
+package suggest
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+)
+
+type entry struct {
+	key         string
+	suggestions []models.Suggestion
+	expires     time.Time
+}
+
+// Cache holds the suggestions of up to size queries for ttl, evicting the
+// least recently used. It records the changes of the song service, and
+// every change of the library empties it, so renamed and deleted songs
+// drop out of suggestions right away rather than after ttl.
+type Cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+}
+
+func NewCache(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the suggestions cached for key.
+func (c *Cache) Get(key string) ([]models.Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if c.now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+
+	return e.suggestions, true
+}
+
+// Put caches the suggestions of key.
+func (c *Cache) Put(key string, suggestions []models.Suggestion) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{
+		key:         key,
+		suggestions: suggestions,
+		expires:     c.now().Add(c.ttl),
+	})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// Invalidate empties the cache.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *Cache) SongCreated() { c.Invalidate() }
+func (c *Cache) SongUpdated() { c.Invalidate() }
+func (c *Cache) SongDeleted() { c.Invalidate() }
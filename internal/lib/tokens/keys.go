@@ -0,0 +1,120 @@
+package tokens
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown key id")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrNoSigningKey     = errors.New("signing key is not configured")
+	ErrKeyAlgMismatch   = errors.New("key does not match algorithm")
+	ErrPrivateKeyNeeded = errors.New("signing key must be a private key")
+)
+
+// Key is a single entry of a key set. HMAC keys carry the shared secret,
+// RSA keys carry a public key and, when the service may sign with it, the
+// private key as well.
+type Key struct {
+	ID         string
+	Alg        string
+	Secret     []byte
+	PublicKey  *rsa.PublicKey
+	PrivateKey *rsa.PrivateKey
+}
+
+// KeySet holds every key the service accepts, indexed by kid, and the kid
+// used to sign new tokens. Rotation is done by adding a new key, switching
+// the signing kid to it and removing the old key once issued tokens expire.
+type KeySet struct {
+	keys       map[string]Key
+	signingKID string
+}
+
+// NewKeySet builds a key set from HMAC secrets and RSA PEM files, both keyed by kid.
+func NewKeySet(signingKID string, hmacSecrets map[string]string, rsaKeyFiles map[string]string) (*KeySet, error) {
+	const op = "lib.tokens.NewKeySet"
+
+	ks := &KeySet{
+		keys:       make(map[string]Key, len(hmacSecrets)+len(rsaKeyFiles)),
+		signingKID: signingKID,
+	}
+
+	for kid, secret := range hmacSecrets {
+		ks.keys[kid] = Key{ID: kid, Alg: AlgHS256, Secret: []byte(secret)}
+	}
+
+	for kid, path := range rsaKeyFiles {
+		key, err := loadRSAKey(kid, path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		ks.keys[kid] = key
+	}
+
+	signing, ok := ks.keys[signingKID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", op, ErrNoSigningKey)
+	}
+
+	if signing.Alg == AlgRS256 && signing.PrivateKey == nil {
+		return nil, fmt.Errorf("%s: %w", op, ErrPrivateKeyNeeded)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) signingKey() Key {
+	return ks.keys[ks.signingKID]
+}
+
+func (ks *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Alg {
+		return nil, ErrKeyAlgMismatch
+	}
+
+	switch key.Alg {
+	case AlgHS256:
+		return key.Secret, nil
+	case AlgRS256:
+		return key.PublicKey, nil
+	default:
+		return nil, ErrUnsupportedAlg
+	}
+}
+
+func loadRSAKey(kid, path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, fmt.Errorf("read key %q: %w", kid, err)
+	}
+
+	if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return Key{ID: kid, Alg: AlgRS256, PublicKey: &private.PublicKey, PrivateKey: private}, nil
+	}
+
+	public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return Key{}, fmt.Errorf("parse key %q: %w", kid, err)
+	}
+
+	return Key{ID: kid, Alg: AlgRS256, PublicKey: public}, nil
+}
@@ -0,0 +1,125 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	Roles []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Manager struct {
+	keys      *KeySet
+	issuer    string
+	accessTTL time.Duration
+}
+
+func NewManager(keys *KeySet, issuer string, accessTTL time.Duration) *Manager {
+	return &Manager{
+		keys:      keys,
+		issuer:    issuer,
+		accessTTL: accessTTL,
+	}
+}
+
+// Issue signs a new access token for subject with the current signing key.
+func (m *Manager) Issue(subject string, roles []string) (string, *Claims, error) {
+	const op = "lib.tokens.Issue"
+
+	jti, err := randomString(16)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    m.issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessTTL)),
+		},
+	}
+
+	key := m.keys.signingKey()
+
+	var token *jwt.Token
+	var signed string
+
+	switch key.Alg {
+	case AlgHS256:
+		token = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = key.ID
+		signed, err = token.SignedString(key.Secret)
+	case AlgRS256:
+		token = jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = key.ID
+		signed, err = token.SignedString(key.PrivateKey)
+	default:
+		return "", nil, fmt.Errorf("%s: %w", op, ErrUnsupportedAlg)
+	}
+
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return signed, claims, nil
+}
+
+// Parse verifies the signature, algorithm, issuer and time claims of raw.
+func (m *Manager) Parse(raw string) (*Claims, error) {
+	const op = "lib.tokens.Parse"
+
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(raw, claims, m.keys.verificationKey,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", op, ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// NewOpaque returns a random opaque token and its hash for storage.
+func NewOpaque() (token, hash string, err error) {
+	token, err = randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex-encoded SHA-256 of an opaque token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,271 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "songs-lib"
+
+func newManager(t *testing.T, signingKID string, hmacSecrets, rsaKeyFiles map[string]string) *Manager {
+	t.Helper()
+
+	ks, err := NewKeySet(signingKID, hmacSecrets, rsaKeyFiles)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	return NewManager(ks, issuer, time.Minute)
+}
+
+func issue(t *testing.T, m *Manager, subject string) string {
+	t.Helper()
+
+	raw, _, err := m.Issue(subject, []string{"editor"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	return raw
+}
+
+// writeRSAKey writes a new RSA key to a PEM file, the private key or only
+// its public part, and returns the key and the path.
+func writeRSAKey(t *testing.T, private bool) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if !private {
+		der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+		if err != nil {
+			t.Fatalf("failed to marshal public key: %v", err)
+		}
+
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	return key, path
+}
+
+func assertRejected(t *testing.T, m *Manager, raw string, reason error) {
+	t.Helper()
+
+	_, err := m.Parse(raw)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Parse() error = %v, want ErrInvalidToken", err)
+	}
+
+	if reason != nil && !strings.Contains(err.Error(), reason.Error()) {
+		t.Errorf("Parse() error = %v, want it caused by %v", err, reason)
+	}
+}
+
+func TestIssueAndParse(t *testing.T) {
+	m := newManager(t, "k1", map[string]string{"k1": "secret-one"}, nil)
+
+	raw, issued, err := m.Issue("alice", []string{"editor"})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	claims, err := m.Parse(raw)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if claims.Subject != "alice" || claims.Issuer != issuer || claims.ID != issued.ID || len(claims.Roles) != 1 || claims.Roles[0] != "editor" {
+		t.Errorf("Parse() = %+v, want the claims issued %+v", claims, issued)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	secrets := map[string]string{"2024-01": "old-secret"}
+	before := newManager(t, "2024-01", secrets, nil)
+	oldToken := issue(t, before, "alice")
+
+	// The new key signs, the old one still verifies what it signed.
+	secrets = map[string]string{"2024-01": "old-secret", "2024-02": "new-secret"}
+	during := newManager(t, "2024-02", secrets, nil)
+	newToken := issue(t, during, "bob")
+
+	token, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+
+	if kid := token.Header["kid"]; kid != "2024-02" {
+		t.Errorf("kid of a new token = %v, want 2024-02", kid)
+	}
+
+	for name, raw := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := during.Parse(raw); err != nil {
+			t.Errorf("Parse() of the %s token during rotation error = %v", name, err)
+		}
+	}
+
+	// Once the old key is retired its tokens are rejected.
+	after := newManager(t, "2024-02", map[string]string{"2024-02": "new-secret"}, nil)
+
+	assertRejected(t, after, oldToken, ErrUnknownKey)
+
+	if _, err := after.Parse(newToken); err != nil {
+		t.Errorf("Parse() of the new token after rotation error = %v", err)
+	}
+}
+
+func TestRejectUnknownKey(t *testing.T) {
+	m := newManager(t, "k1", map[string]string{"k1": "secret-one"}, nil)
+	other := newManager(t, "k2", map[string]string{"k2": "secret-one"}, nil)
+
+	// The same secret under a kid the service does not know is not enough.
+	assertRejected(t, m, issue(t, other, "alice"), ErrUnknownKey)
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+
+	withoutKID, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret-one"))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	assertRejected(t, m, withoutKID, ErrUnknownKey)
+}
+
+func TestRejectAlgorithmMismatch(t *testing.T) {
+	key, path := writeRSAKey(t, true)
+
+	m := newManager(t, "rsa", map[string]string{"hmac": "secret"}, map[string]string{"rsa": path})
+
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "mallory",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+
+	// An HMAC token keyed with the public key of an RSA kid, the classic
+	// algorithm confusion.
+	publicPEM, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = "rsa"
+
+	raw, err := confused.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicPEM}))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	assertRejected(t, m, raw, ErrKeyAlgMismatch)
+
+	// An RSA token under an HMAC kid.
+	rsaToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	rsaToken.Header["kid"] = "hmac"
+
+	raw, err = rsaToken.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	assertRejected(t, m, raw, ErrKeyAlgMismatch)
+
+	// Unsigned tokens are refused before any key is looked up.
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = "hmac"
+
+	raw, err = none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+
+	assertRejected(t, m, raw, nil)
+
+	// The RSA key itself signs and verifies.
+	if _, err := m.Parse(issue(t, m, "alice")); err != nil {
+		t.Errorf("Parse() of an RS256 token error = %v", err)
+	}
+}
+
+func TestRejectClaims(t *testing.T) {
+	secrets := map[string]string{"k1": "secret-one"}
+	m := newManager(t, "k1", secrets, nil)
+
+	ks, err := NewKeySet("k1", secrets, nil)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+
+	assertRejected(t, m, issue(t, NewManager(ks, "someone-else", time.Minute), "alice"), nil)
+	assertRejected(t, m, issue(t, NewManager(ks, issuer, -time.Minute), "alice"), nil)
+	assertRejected(t, m, issue(t, newManager(t, "k1", map[string]string{"k1": "other-secret"}, nil), "alice"), nil)
+}
+
+func TestNewKeySet(t *testing.T) {
+	_, publicPath := writeRSAKey(t, false)
+
+	tests := []struct {
+		name       string
+		signingKID string
+		hmac       map[string]string
+		rsa        map[string]string
+		want       error
+	}{
+		{"signing kid missing", "k2", map[string]string{"k1": "secret"}, nil, ErrNoSigningKey},
+		{"no keys", "k1", nil, nil, ErrNoSigningKey},
+		{"public RSA key cannot sign", "rsa", nil, map[string]string{"rsa": publicPath}, ErrPrivateKeyNeeded},
+		{"public RSA key verifies", "k1", map[string]string{"k1": "secret"}, map[string]string{"rsa": publicPath}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(tt.signingKID, tt.hmac, tt.rsa)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("NewKeySet() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewKeySet("rsa", nil, map[string]string{"rsa": filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("NewKeySet() with a missing key file error = nil")
+	}
+}
+
+func TestOpaque(t *testing.T) {
+	token, hash, err := NewOpaque()
+	if err != nil {
+		t.Fatalf("NewOpaque() error = %v", err)
+	}
+
+	if Hash(token) != hash || len(hash) != 64 {
+		t.Errorf("NewOpaque() hash = %q, want the SHA-256 of the token", hash)
+	}
+
+	other, _, _ := NewOpaque()
+	if other == token {
+		t.Error("NewOpaque() returned the same token twice")
+	}
+}
@@ -0,0 +1,80 @@
+// Package views counts how often songs are read. Counts are kept in memory
+// and written in batches, so reads do not wait for a write.
+package views
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"effective_mobile/internal/lib/logger/sl"
+)
+
+// SaveFunc adds counts, views by song ID, to the stored ones.
+type SaveFunc func(ctx context.Context, counts map[int]int64) error
+
+// Counter counts views until they are flushed.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[int]int64
+}
+
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[int]int64)}
+}
+
+// Viewed counts a view of song id.
+func (c *Counter) Viewed(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[id]++
+}
+
+// Flush saves the views counted since the last flush. When save fails the
+// views are kept for the next one.
+func (c *Counter) Flush(ctx context.Context, save SaveFunc) error {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = make(map[int]int64)
+	c.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	if err := save(ctx, counts); err != nil {
+		c.mu.Lock()
+		for id, n := range counts {
+			c.counts[id] += n
+		}
+		c.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// Run flushes the counter every interval until ctx is done. An interval of
+// 0 or less leaves the views to the flush at shutdown.
+func (c *Counter) Run(ctx context.Context, log *slog.Logger, interval time.Duration, save SaveFunc) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx, save); err != nil {
+				log.Error("failed to save song views", sl.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package wordstats
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// stopWordLists are the words too common to say anything about a song, by
+// base language. Contractions are listed without apostrophes trimmed, as
+// Words returns them.
+var stopWordLists = map[string]string{
+	"en": `a about after again all am an and any are as at be because been before
+		being but by can can't could did didn't do does doesn't don't down for
+		from get got had has have he her here him his how i i'd i'll i'm i've if
+		in into is isn't it it's its just let me my no not now of off oh on
+		once only or our out over she so some than that that's the their them
+		then there these they this those through to too up us very was we were
+		what when where which while who why will with won't would you you're
+		your yeah ooh gonna wanna cause`,
+	"ru": `а без бы был была были было быть в вам вас весь во вот все всё всех вы
+		где да даже для до его ее её если есть ещё еще же за и из или им их к
+		как когда кто ли либо меня мне мной мы на над нам нас не него нее неё
+		нет ни них но ну о об он она они оно от очень по под при про с со так
+		там тебе тебя то тоже только ты у уж уже что чтоб чтобы эта эти это я
+		ой ах эх`,
+	"de": `aber als am an auch auf aus bei bin bis bist da das dass dein deine dem
+		den der des dich die dir doch du ein eine einem einen einer er es für
+		hab habe hat hatte ich ihr im in ist ja kein mein meine mich mir mit
+		nicht noch nur ob oder ohne schon sein sich sie sind so um und uns
+		von vor war was wenn wer wie wir wird zu zum zur`,
+	"fr": `à au aux avec ce ces c'est dans de des du elle en est et eux il ils je
+		j'ai la le les leur lui ma mais me mes moi mon ne nos notre nous on ou
+		par pas pour qu'il que qui sa se ses si son sur ta te tes toi ton tu
+		un une vos votre vous y`,
+	"es": `a al algo como con de del el ella en era es esa ese eso esta este estoy
+		fue ha hay la las le les lo los me mi mis muy más ni no nos o para pero
+		por porque que qué se si sin sobre su sus te ti tu tú un una uno y ya yo`,
+	"pt": `a ao aos as com como da das de do dos e ela ele em era essa esse eu foi
+		há isso já lhe mais mas me meu minha muito na nas não no nos o os ou para
+		pela pelo por que quando se sem seu sua te teu tu um uma você vou`,
+	"it": `a al alla anche che chi ci come con da dal del della di e è gli ha ho
+		i il in io la le lei lo lui ma me mi mio mia ne nel no noi non per più
+		quando se si sono su sua suo ti tu un una uno vi voi`,
+}
+
+var (
+	stopWords    = make(map[string]map[string]struct{}, len(stopWordLists))
+	allStopWords = make(map[string]struct{})
+)
+
+func init() {
+	for lang, list := range stopWordLists {
+		words := make(map[string]struct{})
+		for _, w := range strings.Fields(list) {
+			words[w] = struct{}{}
+			allStopWords[w] = struct{}{}
+		}
+
+		stopWords[lang] = words
+	}
+}
+
+// StopWords returns the stop words of the language of BCP-47 tag lang. For
+// an empty tag or a language without a list it returns the stop words of
+// every language, which rarely stand for anything else.
+func StopWords(lang string) map[string]struct{} {
+	if tag, err := language.Parse(lang); err == nil {
+		base, _ := tag.Base()
+		if words, ok := stopWords[base.String()]; ok {
+			return words
+		}
+	}
+
+	return allStopWords
+}
+
+// AllStopWords returns the stop words of every language, sorted.
+func AllStopWords() []string {
+	words := make([]string, 0, len(allStopWords))
+	for w := range allStopWords {
+		words = append(words, w)
+	}
+
+	sort.Strings(words)
+
+	return words
+}
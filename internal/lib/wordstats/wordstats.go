@@ -0,0 +1,80 @@
+// Package wordstats counts the words of lyrics.
+package wordstats
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"effective_mobile/internal/domain/models"
+)
+
+// Reading and singing rates, in words per minute, the times are estimated
+// from. Lyrics are sung much slower than prose is read.
+const (
+	ReadingWPM = 200
+	SingingWPM = 110
+)
+
+// Words splits text into lowercase words. Apostrophes inside words are
+// kept, so "don't" is one word, and typographic ones become plain.
+func Words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		f = strings.Trim(strings.ReplaceAll(f, "’", "'"), "'")
+		if f != "" {
+			words = append(words, f)
+		}
+	}
+
+	return words
+}
+
+// Top returns the n most frequent of words that are not in stop, most
+// frequent first and alphabetically among equals.
+func Top(words []string, stop map[string]struct{}, n int) []models.WordCount {
+	counts := make(map[string]int)
+	for _, w := range words {
+		if _, ok := stop[w]; !ok {
+			counts[w]++
+		}
+	}
+
+	top := make([]models.WordCount, 0, len(counts))
+	for w, c := range counts {
+		top = append(top, models.WordCount{Word: w, Count: c})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+
+		return top[i].Word < top[j].Word
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
+
+// Unique returns the number of different words.
+func Unique(words []string) int {
+	seen := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		seen[w] = struct{}{}
+	}
+
+	return len(seen)
+}
+
+// Seconds estimates how long words take at wpm words per minute.
+func Seconds(words, wpm int) int {
+	return (words*60 + wpm - 1) / wpm
+}
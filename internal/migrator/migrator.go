@@ -0,0 +1,159 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+
+	"effective_mobile/migrations"
+)
+
+// autoMigrateLockID is the Postgres advisory lock key taken by AutoMigrate,
+// so that only one replica migrates at a time.
+const autoMigrateLockID = 7_214_530_981
+
+var (
+	ErrSchemaTooNew   = errors.New("database schema is newer than this binary")
+	ErrSchemaOutdated = errors.New("database schema is older than this binary")
+	ErrDirty          = errors.New("database schema is dirty")
+)
+
+// Source opens the migrations embedded into the binary.
+func Source() (source.Driver, error) {
+	return iofs.New(migrations.FS, ".")
+}
+
+// Latest returns the highest migration version known to src.
+func Latest(src source.Driver) (uint, error) {
+	v, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		next, err := src.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			return v, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		v = next
+	}
+}
+
+// AutoMigrate applies pending embedded migrations to db while holding an
+// advisory lock. It refuses to touch a dirty schema or one at a version
+// newer than the embedded migrations.
+func AutoMigrate(ctx context.Context, db *sql.DB, migrationsTable string) (uint, error) {
+	const op = "migrator.AutoMigrate"
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, autoMigrateLockID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, autoMigrateLockID)
+
+	src, err := Source()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	latest, err := Latest(src)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The driver gets a connection of its own: closing a driver made with
+	// postgres.WithInstance would close db as well.
+	driverConn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, driverConn, &postgres.Config{MigrationsTable: migrationsTable})
+	if err != nil {
+		driverConn.Close()
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer m.Close()
+
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("%s: %w at version %d", op, ErrDirty, current)
+	}
+
+	if current > latest {
+		return 0, fmt.Errorf("%s: %w: version %d, latest known %d", op, ErrSchemaTooNew, current, latest)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return latest, nil
+}
+
+// CheckVersion compares the schema version recorded in migrationsTable with
+// the embedded migrations and fails unless they match and the schema is clean.
+func CheckVersion(ctx context.Context, db *sql.DB, migrationsTable string) error {
+	const op = "migrator.CheckVersion"
+
+	src, err := Source()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer src.Close()
+
+	latest, err := Latest(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var current uint
+	var dirty bool
+
+	query := fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, pq.QuoteIdentifier(migrationsTable))
+
+	err = db.QueryRowContext(ctx, query).Scan(&current, &dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("%s: %w at version %d", op, ErrDirty, current)
+	case current > latest:
+		return fmt.Errorf("%s: %w: version %d, latest known %d", op, ErrSchemaTooNew, current, latest)
+	case current < latest:
+		return fmt.Errorf("%s: %w: version %d, latest known %d", op, ErrSchemaOutdated, current, latest)
+	}
+
+	return nil
+}
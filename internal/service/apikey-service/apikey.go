@@ -0,0 +1,132 @@
+package apikeyservice
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/rbac"
+	"effective_mobile/internal/lib/tokens"
+	"effective_mobile/internal/service"
+)
+
+var tracer = otel.Tracer("effective_mobile/internal/service/apikey-service")
+
+const (
+	keyPrefix       = "slk_"
+	displayedPrefix = 12
+)
+
+type APIKeyService struct {
+	keyStorage KeyStorage
+	authorizer Authorizer
+}
+
+type KeyStorage interface {
+	SaveAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	APIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type Authorizer interface {
+	Permits(principal models.Principal, perm rbac.Permission) bool
+}
+
+func New(keyStorage KeyStorage, authorizer Authorizer) *APIKeyService {
+	return &APIKeyService{
+		keyStorage: keyStorage,
+		authorizer: authorizer,
+	}
+}
+
+// Create generates a new API key. The plain key is returned only here; the
+// storage keeps its hash and a short prefix to tell keys apart.
+func (s *APIKeyService) Create(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	expiresAt *time.Time,
+	allowedIPs []string,
+) (models.APIKey, string, error) {
+	const op = "service/apikey-service/Create"
+
+	ctx, span := tracer.Start(ctx, "APIKeyService.Create")
+	defer span.End()
+
+	if err := s.authorize(ctx); err != nil {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, scope := range scopes {
+		if !rbac.IsScope(scope) {
+			return models.APIKey{}, "", fmt.Errorf("%s: %w: %q", op, service.ErrInvalidScope, scope)
+		}
+	}
+
+	for _, entry := range allowedIPs {
+		if _, _, err := net.ParseCIDR(entry); err != nil && net.ParseIP(entry) == nil {
+			return models.APIKey{}, "", fmt.Errorf("%s: %w: %q", op, service.ErrInvalidIP, entry)
+		}
+	}
+
+	secret, _, err := tokens.NewOpaque()
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	plain := keyPrefix + secret
+
+	key, err := s.keyStorage.SaveAPIKey(ctx, models.APIKey{
+		Name:       name,
+		Prefix:     plain[:displayedPrefix],
+		Hash:       tokens.Hash(plain),
+		Scopes:     scopes,
+		AllowedIPs: allowedIPs,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, plain, nil
+}
+
+func (s *APIKeyService) APIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "service/apikey-service/APIKeys"
+
+	ctx, span := tracer.Start(ctx, "APIKeyService.APIKeys")
+	defer span.End()
+
+	if err := s.authorize(ctx); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.keyStorage.APIKeys(ctx)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, id int) error {
+	const op = "service/apikey-service/Revoke"
+
+	ctx, span := tracer.Start(ctx, "APIKeyService.Revoke")
+	defer span.End()
+
+	if err := s.authorize(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.keyStorage.RevokeAPIKey(ctx, id)
+}
+
+func (s *APIKeyService) authorize(ctx context.Context) error {
+	p, ok := principal.FromContext(ctx)
+	if !ok || !s.authorizer.Permits(p, rbac.PermAPIKeysManage) {
+		return service.ErrForbidden
+	}
+
+	return nil
+}
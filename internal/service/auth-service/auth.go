@@ -0,0 +1,316 @@
+package authservice
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/tokens"
+	"effective_mobile/internal/service"
+	"effective_mobile/internal/storage"
+)
+
+var tracer = otel.Tracer("effective_mobile/internal/service/auth-service")
+
+const tokenTypeBearer = "Bearer"
+
+type AuthService struct {
+	users        map[string]models.User
+	tokenManager TokenManager
+	tokenStorage TokenStorage
+	keyProvider  APIKeyProvider
+	accessTTL    time.Duration
+	refreshTTL   time.Duration
+}
+
+type TokenManager interface {
+	Issue(subject string, roles []string) (string, *tokens.Claims, error)
+	Parse(raw string) (*tokens.Claims, error)
+}
+
+type TokenStorage interface {
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RefreshToken(ctx context.Context, hash string) (models.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, hash string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type APIKeyProvider interface {
+	APIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+func New(
+	users map[string]models.User,
+	tokenManager TokenManager,
+	tokenStorage TokenStorage,
+	keyProvider APIKeyProvider,
+	accessTTL, refreshTTL time.Duration,
+) *AuthService {
+	return &AuthService{
+		users:        users,
+		tokenManager: tokenManager,
+		tokenStorage: tokenStorage,
+		keyProvider:  keyProvider,
+		accessTTL:    accessTTL,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+// CheckCredentials returns the principal of the configured user matching
+// username and password.
+func (s *AuthService) CheckCredentials(username, password string) (models.Principal, bool) {
+	user, ok := s.users[username]
+	if !ok {
+		return models.Principal{}, false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(password)) != 1 {
+		return models.Principal{}, false
+	}
+
+	return models.Principal{
+		Subject: username,
+		Method:  models.AuthMethodBasic,
+		Roles:   user.Roles,
+	}, true
+}
+
+func (s *AuthService) Login(ctx context.Context, username, password string) (models.TokenPair, error) {
+	const op = "service/auth-service/Login"
+
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	if _, ok := s.CheckCredentials(username, password); !ok {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, service.ErrInvalidCredentials)
+	}
+
+	pair, err := s.issuePair(ctx, username)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// refresh token is revoked, so each one can be used only once.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	const op = "service/auth-service/Refresh"
+
+	ctx, span := tracer.Start(ctx, "AuthService.Refresh")
+	defer span.End()
+
+	hash := tokens.Hash(refreshToken)
+
+	stored, err := s.tokenStorage.RefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.Revoked || time.Now().After(stored.ExpiresAt) {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+	}
+
+	// Users removed from the configuration can no longer refresh.
+	if _, ok := s.users[stored.Subject]; !ok {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+	}
+
+	if err := s.tokenStorage.RevokeRefreshToken(ctx, hash); err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return models.TokenPair{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+		}
+
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pair, err := s.issuePair(ctx, stored.Subject)
+	if err != nil {
+		return models.TokenPair{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return pair, nil
+}
+
+// Logout puts the access token of principal on the revocation list and
+// revokes refreshToken if it is given.
+func (s *AuthService) Logout(ctx context.Context, principal models.Principal, refreshToken string) error {
+	const op = "service/auth-service/Logout"
+
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	if principal.Method == models.AuthMethodBearer {
+		if err := s.tokenStorage.RevokeAccessToken(ctx, principal.TokenID, principal.ExpiresAt); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	hash := tokens.Hash(refreshToken)
+
+	stored, err := s.tokenStorage.RefreshToken(ctx, hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.Subject != principal.Subject {
+		return fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+	}
+
+	if err := s.tokenStorage.RevokeRefreshToken(ctx, hash); err != nil && !errors.Is(err, storage.ErrTokenNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Authenticate validates a bearer access token and returns its principal.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (models.Principal, error) {
+	const op = "service/auth-service/Authenticate"
+
+	ctx, span := tracer.Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+
+	claims, err := s.tokenManager.Parse(accessToken)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+	}
+
+	revoked, err := s.tokenStorage.IsAccessTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if revoked {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, service.ErrInvalidToken)
+	}
+
+	return models.Principal{
+		Subject:   claims.Subject,
+		Method:    models.AuthMethodBearer,
+		Roles:     claims.Roles,
+		TokenID:   claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
+
+// AuthenticateAPIKey validates an X-API-Key value presented from ip and
+// returns a principal carrying the key scopes. Revoked keys are rejected as
+// soon as the revocation is stored.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, key string, ip net.IP) (models.Principal, error) {
+	const op = "service/auth-service/AuthenticateAPIKey"
+
+	ctx, span := tracer.Start(ctx, "AuthService.AuthenticateAPIKey")
+	defer span.End()
+
+	stored, err := s.keyProvider.APIKeyByHash(ctx, tokens.Hash(key))
+	if err != nil {
+		if errors.Is(err, storage.ErrKeyNotFound) {
+			return models.Principal{}, fmt.Errorf("%s: %w", op, service.ErrInvalidAPIKey)
+		}
+
+		return models.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if stored.RevokedAt != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w: revoked", op, service.ErrInvalidAPIKey)
+	}
+
+	if stored.ExpiresAt != nil && time.Now().After(*stored.ExpiresAt) {
+		return models.Principal{}, fmt.Errorf("%s: %w: expired", op, service.ErrInvalidAPIKey)
+	}
+
+	if !ipAllowed(stored.AllowedIPs, ip) {
+		return models.Principal{}, fmt.Errorf("%s: %w: address %s is not allowed", op, service.ErrInvalidAPIKey, ip)
+	}
+
+	if err := s.keyProvider.TouchAPIKey(ctx, stored.ID); err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.Principal{
+		Subject: "apikey:" + strconv.Itoa(stored.ID),
+		Method:  models.AuthMethodAPIKey,
+		Scopes:  stored.Scopes,
+	}, nil
+}
+
+// ipAllowed matches ip against a list of addresses and CIDR networks.
+// An empty list allows any address.
+func ipAllowed(allowed []string, ip net.IP) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range allowed {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+
+			continue
+		}
+
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// issuePair embeds the roles the user has right now, so role changes take
+// effect on the next refresh.
+func (s *AuthService) issuePair(ctx context.Context, subject string) (models.TokenPair, error) {
+	accessToken, _, err := s.tokenManager.Issue(subject, s.users[subject].Roles)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refreshToken, hash, err := tokens.NewOpaque()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	err = s.tokenStorage.SaveRefreshToken(ctx, models.RefreshToken{
+		Hash:      hash,
+		Subject:   subject,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
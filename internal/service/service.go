@@ -2,8 +2,43 @@ package service
 
 import "errors"
 
+// DetailedError is a service error with a detail for the client, such as
+// where in the input the error was found.
+type DetailedError struct {
+	Err    error
+	Detail string
+}
+
+func (e *DetailedError) Error() string {
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *DetailedError) Unwrap() error {
+	return e.Err
+}
+
 var (
 	ErrInvalidVerseNumber = errors.New("invalid verse number")
 	ErrInvalidDateFormat  = errors.New("invalid date format")
 	ErrEmptyUpdate        = errors.New("update data is epmty")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrInvalidScope       = errors.New("invalid scope")
+	ErrInvalidIP          = errors.New("invalid ip address or network")
+	ErrInvalidSection     = errors.New("invalid section type")
+	ErrInvalidLRC         = errors.New("invalid lrc lyrics")
+	ErrInvalidOffset      = errors.New("invalid playback offset")
+	ErrLyricsNotSynced    = errors.New("lyrics are not synced")
+	ErrInvalidLanguage    = errors.New("invalid language tag")
+	ErrInvalidChordPro    = errors.New("invalid chordpro chord sheet")
+	ErrInvalidTranspose   = errors.New("invalid transposition")
+	ErrInvalidCapo        = errors.New("invalid capo")
+	ErrInvalidTop         = errors.New("invalid number of top words")
+	ErrInvalidThreshold   = errors.New("invalid similarity threshold")
+	ErrInvalidLimit       = errors.New("invalid limit")
+	ErrMergeSelf          = errors.New("song cannot be merged into itself")
+	ErrInvalidSuggestType = errors.New("invalid suggestion type")
+	ErrIndexNotReady      = errors.New("similar songs index is not loaded yet")
 )
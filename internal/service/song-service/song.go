@@ -1,6 +1,7 @@
 package songservice
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,41 +9,184 @@ import (
 
 	"effective_mobile/internal/clients"
 	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/lib/chords"
+	"effective_mobile/internal/lib/dedup"
+	"effective_mobile/internal/lib/langdetect"
+	"effective_mobile/internal/lib/locale"
+	"effective_mobile/internal/lib/lyrics"
+	"effective_mobile/internal/lib/principal"
+	"effective_mobile/internal/lib/rbac"
+	"effective_mobile/internal/lib/wordstats"
 	"effective_mobile/internal/service"
+	"effective_mobile/internal/storage"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("effective_mobile/internal/service/song-service")
+
+// maxTranspose is how far chord sheets can be transposed either way.
+const maxTranspose = 12
+
+// Number of top words lyrics statistics list by default and at most.
+const (
+	defaultTopWords = 10
+	maxTopWords     = 100
+)
+
+// Lyrics similarity from which songs are listed as duplicates by default,
+// and how many pairs are listed by default and at most.
+const (
+	defaultDuplicateThreshold = 0.8
+	defaultDuplicates         = 100
+	maxDuplicates             = 1000
+)
+
+// Number of suggestions returned by default and at most.
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 50
+)
+
+// Number of similar songs returned by default and at most.
+const (
+	defaultSimilar = 10
+	maxSimilar     = 50
 )
 
 type SongService struct {
 	songSaver    SongSaver
 	songProvider SongProvider
+	songDeleter  SongDeleter
 	externalAPI  ExternalRequester
+	authorizer   Authorizer
+	recorder     ChangeRecorder
+	suggestions  SuggestionCache
+	views        ViewCounter
+	similar      SimilarIndex
 }
 
 type SongSaver interface {
-	SaveSong(song models.SongData) (int, error)
-	UpdateSong(id int, updateSong models.UpdateSongData) error
+	SaveSong(ctx context.Context, song models.SongData) (int, error)
+	UpdateSong(ctx context.Context, id int, updateSong models.UpdateSongData) error
+	SaveSyncedLyrics(ctx context.Context, id int, lines []models.TimedLine, text string, detected models.DetectedLanguage, fp models.Fingerprint) error
+	SaveTranslation(ctx context.Context, id int, translation models.Translation, detected models.DetectedLanguage, fp models.Fingerprint) error
+	SaveChords(ctx context.Context, id int, chordPro string) error
+	MergeSongs(ctx context.Context, sourceID, targetID int, actor string) (models.SongData, error)
 }
 
 type SongProvider interface {
-	Songs(filter models.FilterSongData) ([]models.SongData, error)
-	Text(id int) (string, error)
+	Songs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error)
+	AllSongs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error)
+	Text(ctx context.Context, id int) (string, error)
+	Lyrics(ctx context.Context, id int) (models.Lyrics, error)
+	Translations(ctx context.Context, id int) (string, []models.Translation, error)
+	Chords(ctx context.Context, id int) (string, error)
+	CatalogStats(ctx context.Context, filter models.FilterSongData, stopWords []string, top int) (models.CatalogStats, error)
+	Song(ctx context.Context, id int) (models.SongData, error)
+	Duplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicatePair, error)
+	Revisions(ctx context.Context, id int) ([]models.Revision, error)
+	Suggest(ctx context.Context, q, kind string, limit int) ([]models.Suggestion, error)
+}
+
+type SongDeleter interface {
+	DeleteSong(ctx context.Context, id int) error
+	DeleteTranslation(ctx context.Context, id int, lang string) error
+	DeleteChords(ctx context.Context, id int) error
 }
 
 type ExternalRequester interface {
-	FetchSongDetails(group, song string) (*models.SongData, error)
+	FetchSongDetails(ctx context.Context, group, song string) (*models.SongData, error)
+}
+
+type Authorizer interface {
+	Permits(principal models.Principal, perm rbac.Permission) bool
+}
+
+// ChangeRecorder counts successful changes of the library.
+type ChangeRecorder interface {
+	SongCreated()
+	SongUpdated()
+	SongDeleted()
+}
+
+// ChangeRecorders passes every change to each of its recorders.
+type ChangeRecorders []ChangeRecorder
+
+func (r ChangeRecorders) SongCreated() {
+	for _, recorder := range r {
+		recorder.SongCreated()
+	}
+}
+
+func (r ChangeRecorders) SongUpdated() {
+	for _, recorder := range r {
+		recorder.SongUpdated()
+	}
+}
+
+func (r ChangeRecorders) SongDeleted() {
+	for _, recorder := range r {
+		recorder.SongDeleted()
+	}
+}
+
+// SuggestionCache keeps suggestions by query. It has to drop them when the
+// library changes, which it can learn by being one of the recorders.
+type SuggestionCache interface {
+	Get(key string) ([]models.Suggestion, bool)
+	Put(key string, suggestions []models.Suggestion)
 }
 
-func New(songSaver SongSaver, songProvider SongProvider, externalAPI ExternalRequester) *SongService {
+// ViewCounter counts how often songs are read, which ranks suggestions.
+type ViewCounter interface {
+	Viewed(id int)
+}
+
+// SimilarIndex ranks songs by their similarity to a song. The service keeps
+// it up to date with the songs it changes.
+type SimilarIndex interface {
+	Put(song models.SongData)
+	Remove(id int)
+	Ready() bool
+	Similar(id, limit int) ([]models.SimilarSong, bool)
+}
+
+func New(
+	songSaver SongSaver,
+	songProvider SongProvider,
+	songDeleter SongDeleter,
+	externalAPI ExternalRequester,
+	authorizer Authorizer,
+	recorder ChangeRecorder,
+	suggestions SuggestionCache,
+	views ViewCounter,
+	similar SimilarIndex,
+) *SongService {
 	return &SongService{
 		songSaver:    songSaver,
 		songProvider: songProvider,
+		songDeleter:  songDeleter,
 		externalAPI:  externalAPI,
+		authorizer:   authorizer,
+		recorder:     recorder,
+		suggestions:  suggestions,
+		views:        views,
+		similar:      similar,
 	}
 }
 
-func (s *SongService) SaveSong(group, song string) (int, error) {
+func (s *SongService) SaveSong(ctx context.Context, group, song string) (int, error) {
 	const op = "service/song-service/SaveSong"
 
-	songDetail, err := s.externalAPI.FetchSongDetails(group, song)
+	ctx, span := tracer.Start(ctx, "SongService.SaveSong")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsCreate); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songDetail, err := s.externalAPI.FetchSongDetails(ctx, group, song)
 	if err != nil {
 		if errors.Is(err, clients.ErrBadRequest) {
 			return 0, fmt.Errorf("%s: %w", op, clients.ErrBadRequest)
@@ -57,29 +201,52 @@ func (s *SongService) SaveSong(group, song string) (int, error) {
 
 	parsedDate, err := time.Parse("02.01.2006", songDetail.ReleaseDate)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, service.ErrInvalidDateFormat)
+		return 0, fmt.Errorf("%s: %w: invalid release date %q", op, clients.ErrInternal, songDetail.ReleaseDate)
 	}
 	songDetail.ReleaseDate = parsedDate.Format("2006-01-02")
 
+	detected := langdetect.Detect(songDetail.Text)
+	fp := fingerprint(songDetail.Text)
+
 	songData := models.SongData{
-		Group:       group,
-		Song:        song,
-		ReleaseDate: songDetail.ReleaseDate,
-		Text:        songDetail.Text,
-		Link:        songDetail.Link,
+		Group:              group,
+		Song:               song,
+		ReleaseDate:        songDetail.ReleaseDate,
+		Text:               songDetail.Text,
+		Link:               songDetail.Link,
+		Language:           detected.Lang,
+		LanguageConfidence: detected.Confidence,
+		CanonicalKey:       dedup.CanonicalKey(group, song),
+		Fingerprint:        &fp,
 	}
 
-	id, err := s.songSaver.SaveSong(songData)
+	id, err := s.songSaver.SaveSong(ctx, songData)
 	if err != nil {
 		return 0, err
 	}
 
+	s.reindex(ctx, id)
+	s.recorder.SongCreated()
+
 	return id, nil
 }
 
-func (s *SongService) UpdateSong(id int, updateSong models.UpdateSongData) error {
+func (s *SongService) UpdateSong(ctx context.Context, id int, updateSong models.UpdateSongData) error {
 	const op = "service/song-service/UpdateSong"
 
+	ctx, span := tracer.Start(ctx, "SongService.UpdateSong")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updateSong.Group != nil || updateSong.Song != nil {
+		if err := s.authorize(ctx, rbac.PermSongsUpdateIdentity); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	if isEmptyUpdate(updateSong) {
 		return fmt.Errorf("%s: %w", op, service.ErrEmptyUpdate)
 	}
@@ -94,17 +261,818 @@ func (s *SongService) UpdateSong(id int, updateSong models.UpdateSongData) error
 		updateSong.ReleaseDate = &formattedDate
 	}
 
-	err := s.songSaver.UpdateSong(id, updateSong)
+	if updateSong.Text != nil {
+		detected := langdetect.Detect(*updateSong.Text)
+		updateSong.Language = &detected
+
+		fp := fingerprint(*updateSong.Text)
+		updateSong.Fingerprint = &fp
+	}
+
+	if updateSong.Group != nil || updateSong.Song != nil {
+		key, err := s.canonicalKey(ctx, id, updateSong)
+		if err != nil {
+			return err
+		}
+
+		updateSong.CanonicalKey = &key
+	}
+
+	err := s.songSaver.UpdateSong(ctx, id, updateSong)
 	if err != nil {
 		return err
 	}
 
+	s.reindex(ctx, id)
+	s.recorder.SongUpdated()
+
 	return nil
 }
 
-func (s *SongService) Songs(filter models.FilterSongData) ([]models.SongData, error) {
+func (s *SongService) DeleteSong(ctx context.Context, id int) error {
+	const op = "service/song-service/DeleteSong"
+
+	ctx, span := tracer.Start(ctx, "SongService.DeleteSong")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsDelete); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.songDeleter.DeleteSong(ctx, id); err != nil {
+		return err
+	}
+
+	s.similar.Remove(id)
+	s.recorder.SongDeleted()
+
+	return nil
+}
+
+func (s *SongService) Songs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error) {
 	const op = "service/song-service/Songs"
 
+	ctx, span := tracer.Start(ctx, "SongService.Songs")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter, err := normalizeFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songs, err := s.songProvider.Songs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+// ExportSongs returns every song matching filter without paging.
+func (s *SongService) ExportSongs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error) {
+	const op = "service/song-service/ExportSongs"
+
+	ctx, span := tracer.Start(ctx, "SongService.ExportSongs")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsExport); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter, err := normalizeFilter(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songs, err := s.songProvider.AllSongs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+// Text returns verse number verse of song id. When lang or acceptLanguage,
+// the value of an Accept-Language header, is given and the song has lyrics
+// in a matching language, the verse is taken from them. Verses of a
+// translation are aligned with the original, and the result carries a
+// warning when their counts diverge. An explicit lang that matches nothing
+// is an error, while an unmatched Accept-Language falls back to the original.
+func (s *SongService) Text(ctx context.Context, id, verse int, lang, acceptLanguage string) (models.VerseText, error) {
+	const op = "service/song-service/Text"
+
+	ctx, span := tracer.Start(ctx, "SongService.Text")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.VerseText{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if verse < 1 {
+		return models.VerseText{}, fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
+	}
+
+	if lang != "" {
+		if _, err := locale.Canonical(lang); err != nil {
+			return models.VerseText{}, fmt.Errorf("%s: %w", op, service.ErrInvalidLanguage)
+		}
+	}
+
+	var result models.VerseText
+	var verses []string
+
+	if lang == "" && acceptLanguage == "" {
+		text, err := s.songProvider.Text(ctx, id)
+		if err != nil {
+			return models.VerseText{}, err
+		}
+
+		verses = lyrics.Verses(lyrics.Parse(text))
+	} else {
+		text, translations, err := s.songProvider.Translations(ctx, id)
+		if err != nil {
+			return models.VerseText{}, err
+		}
+
+		chosen, ok := locale.Negotiate(languages(translations), lang, acceptLanguage)
+		if !ok && lang != "" {
+			return models.VerseText{}, fmt.Errorf("%s: %w", op, storage.ErrTranslationNotFound)
+		}
+
+		translation, _ := findTranslation(translations, chosen)
+
+		if ok && !translation.Original {
+			var originalCount int
+			var aligned bool
+
+			verses, originalCount, aligned = lyrics.AlignedVerses(text, translation.Text)
+
+			result.Lang = translation.Lang
+			if !aligned {
+				result.Warning = verseCountWarning(translation.Lang, len(verses), originalCount)
+			}
+		} else {
+			verses = lyrics.Verses(lyrics.Parse(text))
+			result.Lang = originalLanguage(translations)
+		}
+	}
+
+	if verse > len(verses) {
+		return models.VerseText{}, fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
+	}
+
+	result.Text = verses[verse-1]
+
+	// Reading the lyrics from the start counts as a view of the song.
+	if verse == 1 {
+		s.views.Viewed(id)
+	}
+
+	return result, nil
+}
+
+// Sections returns the lyrics of song id split into typed sections, only
+// those of sectionType when it is not empty.
+func (s *SongService) Sections(ctx context.Context, id int, sectionType string) ([]models.Section, error) {
+	const op = "service/song-service/Sections"
+
+	ctx, span := tracer.Start(ctx, "SongService.Sections")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sectionType != "" && !lyrics.IsSectionType(sectionType) {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidSection)
+	}
+
+	text, err := s.songProvider.Text(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := lyrics.Parse(text)
+	if sectionType != "" {
+		sections = lyrics.Filter(sections, sectionType)
+	}
+
+	return sections, nil
+}
+
+// SetSyncedLyrics stores the LRC lyrics of song id. The plain lyrics are
+// replaced by the text of the timed lines, so verses and sections keep
+// being served from the same lines.
+func (s *SongService) SetSyncedLyrics(ctx context.Context, id int, lrc string) error {
+	const op = "service/song-service/SetSyncedLyrics"
+
+	ctx, span := tracer.Start(ctx, "SongService.SetSyncedLyrics")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lines, err := lyrics.ParseLRC(lrc)
+	if err != nil {
+		detail := strings.TrimPrefix(err.Error(), lyrics.ErrInvalidLRC.Error()+": ")
+
+		return fmt.Errorf("%s: %w", op, &service.DetailedError{Err: service.ErrInvalidLRC, Detail: detail})
+	}
+
+	text := lyrics.PlainText(lines)
+
+	if err := s.songSaver.SaveSyncedLyrics(ctx, id, lines, text, langdetect.Detect(text), fingerprint(text)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.reindex(ctx, id)
+	s.recorder.SongUpdated()
+
+	return nil
+}
+
+// Lyrics returns the plain and, if any, the synced lyrics of song id along
+// with the languages it has lyrics in.
+func (s *SongService) Lyrics(ctx context.Context, id int) (models.Lyrics, error) {
+	const op = "service/song-service/Lyrics"
+
+	ctx, span := tracer.Start(ctx, "SongService.Lyrics")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	songLyrics, err := s.songProvider.Lyrics(ctx, id)
+	if err != nil {
+		return models.Lyrics{}, err
+	}
+
+	_, translations, err := s.songProvider.Translations(ctx, id)
+	if err != nil {
+		return models.Lyrics{}, err
+	}
+
+	for _, t := range translations {
+		songLyrics.Languages = append(songLyrics.Languages, models.Translation{Lang: t.Lang, Original: t.Original})
+	}
+
+	s.views.Viewed(id)
+
+	return songLyrics, nil
+}
+
+// ActiveLine returns the synced line of song id being sung offsetMS into
+// the song.
+func (s *SongService) ActiveLine(ctx context.Context, id, offsetMS int) (models.ActiveLine, error) {
+	const op = "service/song-service/ActiveLine"
+
+	ctx, span := tracer.Start(ctx, "SongService.ActiveLine")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.ActiveLine{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if offsetMS < 0 {
+		return models.ActiveLine{}, fmt.Errorf("%s: %w", op, service.ErrInvalidOffset)
+	}
+
+	songLyrics, err := s.songProvider.Lyrics(ctx, id)
+	if err != nil {
+		return models.ActiveLine{}, err
+	}
+
+	if len(songLyrics.Lines) == 0 {
+		return models.ActiveLine{}, fmt.Errorf("%s: %w", op, service.ErrLyricsNotSynced)
+	}
+
+	return lyrics.ActiveLine(songLyrics.Lines, offsetMS), nil
+}
+
+// Translation returns the lyrics of song id in lang.
+func (s *SongService) Translation(ctx context.Context, id int, lang string) (models.Translation, error) {
+	const op = "service/song-service/Translation"
+
+	ctx, span := tracer.Start(ctx, "SongService.Translation")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.Translation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lang, err := locale.Canonical(lang)
+	if err != nil {
+		return models.Translation{}, fmt.Errorf("%s: %w", op, service.ErrInvalidLanguage)
+	}
+
+	_, translations, err := s.songProvider.Translations(ctx, id)
+	if err != nil {
+		return models.Translation{}, err
+	}
+
+	translation, ok := findTranslation(translations, lang)
+	if !ok {
+		return models.Translation{}, fmt.Errorf("%s: %w", op, storage.ErrTranslationNotFound)
+	}
+
+	return translation, nil
+}
+
+// SaveTranslation creates or replaces the lyrics of song id in
+// translation.Lang. For a translation it returns a warning when its verses
+// do not line up with the original's, which readers of aligned verses will
+// notice.
+func (s *SongService) SaveTranslation(ctx context.Context, id int, translation models.Translation) (string, error) {
+	const op = "service/song-service/SaveTranslation"
+
+	ctx, span := tracer.Start(ctx, "SongService.SaveTranslation")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	lang, err := locale.Canonical(translation.Lang)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, service.ErrInvalidLanguage)
+	}
+	translation.Lang = lang
+
+	// The language and fingerprint are only stored when the text replaces
+	// the song lyrics.
+	var detected models.DetectedLanguage
+	var fp models.Fingerprint
+	if translation.Original && translation.Text != "" {
+		detected = langdetect.Detect(translation.Text)
+		fp = fingerprint(translation.Text)
+	}
+
+	if err := s.songSaver.SaveTranslation(ctx, id, translation, detected, fp); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if translation.Original && translation.Text != "" {
+		s.reindex(ctx, id)
+	}
+
+	s.recorder.SongUpdated()
+
+	if translation.Original {
+		return "", nil
+	}
+
+	text, err := s.songProvider.Text(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	verses, originalCount, aligned := lyrics.AlignedVerses(text, translation.Text)
+	if aligned {
+		return "", nil
+	}
+
+	return verseCountWarning(lang, len(verses), originalCount), nil
+}
+
+// DeleteTranslation deletes the lyrics of song id in lang.
+func (s *SongService) DeleteTranslation(ctx context.Context, id int, lang string) error {
+	const op = "service/song-service/DeleteTranslation"
+
+	ctx, span := tracer.Start(ctx, "SongService.DeleteTranslation")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	lang, err := locale.Canonical(lang)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, service.ErrInvalidLanguage)
+	}
+
+	if err := s.songDeleter.DeleteTranslation(ctx, id, lang); err != nil {
+		return err
+	}
+
+	s.recorder.SongUpdated()
+
+	return nil
+}
+
+// SaveChords stores the ChordPro chord sheet of song id.
+func (s *SongService) SaveChords(ctx context.Context, id int, chordPro string) error {
+	const op = "service/song-service/SaveChords"
+
+	ctx, span := tracer.Start(ctx, "SongService.SaveChords")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := chords.Parse(chordPro); err != nil {
+		return fmt.Errorf("%s: %w: %s", op, service.ErrInvalidChordPro, err)
+	}
+
+	if err := s.songSaver.SaveChords(ctx, id, chordPro); err != nil {
+		return err
+	}
+
+	s.recorder.SongUpdated()
+
+	return nil
+}
+
+// Chords returns the chord sheet of song id transposed and for the capo of
+// opts. With opts.Verse only that verse is returned, numbered like the
+// verses of Text.
+func (s *SongService) Chords(ctx context.Context, id int, opts models.ChordOptions) (models.ChordSheet, error) {
+	const op = "service/song-service/Chords"
+
+	ctx, span := tracer.Start(ctx, "SongService.Chords")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.ChordSheet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if opts.Transpose < -maxTranspose || opts.Transpose > maxTranspose {
+		return models.ChordSheet{}, fmt.Errorf("%s: %w", op, service.ErrInvalidTranspose)
+	}
+
+	if opts.Capo != nil && (*opts.Capo < 0 || *opts.Capo > chords.MaxCapo) {
+		return models.ChordSheet{}, fmt.Errorf("%s: %w", op, service.ErrInvalidCapo)
+	}
+
+	if opts.Verse < 0 {
+		return models.ChordSheet{}, fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
+	}
+
+	chordPro, err := s.songProvider.Chords(ctx, id)
+	if err != nil {
+		return models.ChordSheet{}, err
+	}
+
+	sheet, err := chords.Parse(chordPro)
+	if err != nil {
+		return models.ChordSheet{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	capo := sheet.Capo
+	if opts.Capo != nil {
+		capo = *opts.Capo
+	}
+
+	sheet = chords.Shift(sheet, opts.Transpose, capo)
+
+	if opts.Verse > 0 {
+		verse, ok := findVerse(sheet.Sections, opts.Verse)
+		if !ok {
+			return models.ChordSheet{}, fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
+		}
+
+		sheet.Sections = []models.ChordSection{verse}
+	}
+
+	return sheet, nil
+}
+
+// DeleteChords deletes the chord sheet of song id.
+func (s *SongService) DeleteChords(ctx context.Context, id int) error {
+	const op = "service/song-service/DeleteChords"
+
+	ctx, span := tracer.Start(ctx, "SongService.DeleteChords")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.songDeleter.DeleteChords(ctx, id); err != nil {
+		return err
+	}
+
+	s.recorder.SongUpdated()
+
+	return nil
+}
+
+// Stats returns statistics of the lyrics of song id, or of its lyrics in
+// lang when lang is not empty, with the top most frequent words. A top of 0
+// lists defaultTopWords words.
+func (s *SongService) Stats(ctx context.Context, id int, lang string, top int) (models.LyricsStats, error) {
+	const op = "service/song-service/Stats"
+
+	ctx, span := tracer.Start(ctx, "SongService.Stats")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.LyricsStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	top, err := topWords(top)
+	if err != nil {
+		return models.LyricsStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if lang != "" {
+		lang, err = locale.Canonical(lang)
+		if err != nil {
+			return models.LyricsStats{}, fmt.Errorf("%s: %w", op, service.ErrInvalidLanguage)
+		}
+	}
+
+	text, translations, err := s.songProvider.Translations(ctx, id)
+	if err != nil {
+		return models.LyricsStats{}, err
+	}
+
+	if lang != "" {
+		translation, ok := findTranslation(translations, lang)
+		if !ok {
+			return models.LyricsStats{}, fmt.Errorf("%s: %w", op, storage.ErrTranslationNotFound)
+		}
+
+		if !translation.Original {
+			text = translation.Text
+		}
+	} else {
+		lang = originalLanguage(translations)
+	}
+
+	return lyrics.Stats(text, lang, top), nil
+}
+
+// CatalogStats returns statistics of the lyrics of the songs matching
+// filter: words per group with their top most frequent words, and average
+// length per release year.
+func (s *SongService) CatalogStats(ctx context.Context, filter models.FilterSongData, top int) (models.CatalogStats, error) {
+	const op = "service/song-service/CatalogStats"
+
+	ctx, span := tracer.Start(ctx, "SongService.CatalogStats")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	top, err := topWords(top)
+	if err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter, err = normalizeFilter(filter)
+	if err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Groups sing in different languages, so the stop words of all of them
+	// are left out.
+	stats, err := s.songProvider.CatalogStats(ctx, filter, wordstats.AllStopWords(), top)
+	if err != nil {
+		return models.CatalogStats{}, err
+	}
+
+	return stats, nil
+}
+
+// Duplicates returns up to limit pairs of songs that are likely the same:
+// their names normalize to the same canonical key, or their lyrics are at
+// least threshold similar. A threshold or limit of 0 takes the default.
+func (s *SongService) Duplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicatePair, error) {
+	const op = "service/song-service/Duplicates"
+
+	ctx, span := tracer.Start(ctx, "SongService.Duplicates")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if threshold == 0 {
+		threshold = defaultDuplicateThreshold
+	}
+
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidThreshold)
+	}
+
+	if limit == 0 {
+		limit = defaultDuplicates
+	}
+
+	if limit < 0 || limit > maxDuplicates {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidLimit)
+	}
+
+	pairs, err := s.songProvider.Duplicates(ctx, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pairs, nil
+}
+
+// MergeSong merges song id into song into, which takes what it lacks from
+// it, and deletes song id. Both songs keep a revision of their state
+// before the merge. It returns the merged song.
+func (s *SongService) MergeSong(ctx context.Context, id, into int) (models.SongData, error) {
+	const op = "service/song-service/MergeSong"
+
+	ctx, span := tracer.Start(ctx, "SongService.MergeSong")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.authorize(ctx, rbac.PermSongsDelete); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if id == into {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, service.ErrMergeSelf)
+	}
+
+	p, _ := principal.FromContext(ctx)
+
+	merged, err := s.songSaver.MergeSongs(ctx, id, into, p.Subject)
+	if err != nil {
+		return models.SongData{}, err
+	}
+
+	s.similar.Remove(id)
+	s.similar.Put(merged)
+	s.recorder.SongDeleted()
+	s.recorder.SongUpdated()
+
+	return merged, nil
+}
+
+// Revisions returns the revisions of song id, oldest first, also when it
+// was merged into another song since.
+func (s *SongService) Revisions(ctx context.Context, id int) ([]models.Revision, error) {
+	const op = "service/song-service/Revisions"
+
+	ctx, span := tracer.Start(ctx, "SongService.Revisions")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsUpdate); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revisions, err := s.songProvider.Revisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Suggest returns up to limit group names or song titles, or both for kind
+// models.SuggestAll, matching q as it is being typed. An empty kind
+// suggests both and a limit of 0 takes the default. Suggestions are cached
+// until the library changes.
+func (s *SongService) Suggest(ctx context.Context, q, kind string, limit int) ([]models.Suggestion, error) {
+	const op = "service/song-service/Suggest"
+
+	ctx, span := tracer.Start(ctx, "SongService.Suggest")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch kind {
+	case "":
+		kind = models.SuggestAll
+	case models.SuggestGroup, models.SuggestSong, models.SuggestAll:
+	default:
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidSuggestType)
+	}
+
+	if limit == 0 {
+		limit = defaultSuggestions
+	}
+
+	if limit < 0 || limit > maxSuggestions {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidLimit)
+	}
+
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if q == "" {
+		return []models.Suggestion{}, nil
+	}
+
+	key := fmt.Sprintf("%s|%d|%s", kind, limit, q)
+	if suggestions, ok := s.suggestions.Get(key); ok {
+		return suggestions, nil
+	}
+
+	suggestions, err := s.songProvider.Suggest(ctx, q, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	s.suggestions.Put(key, suggestions)
+
+	return suggestions, nil
+}
+
+// Similar returns up to limit songs most similar to song id, best first,
+// each with the reasons it is similar. A limit of 0 takes the default.
+func (s *SongService) Similar(ctx context.Context, id, limit int) ([]models.SimilarSong, error) {
+	const op = "service/song-service/Similar"
+
+	ctx, span := tracer.Start(ctx, "SongService.Similar")
+	defer span.End()
+
+	if err := s.authorize(ctx, rbac.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if limit == 0 {
+		limit = defaultSimilar
+	}
+
+	if limit < 0 || limit > maxSimilar {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidLimit)
+	}
+
+	if !s.similar.Ready() {
+		return nil, fmt.Errorf("%s: %w", op, service.ErrIndexNotReady)
+	}
+
+	similar, ok := s.similar.Similar(id, limit)
+	if !ok {
+		// The song may have been added by another instance since the
+		// index was loaded.
+		song, err := s.songProvider.Song(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		s.similar.Put(song)
+		similar, _ = s.similar.Similar(id, limit)
+	}
+
+	return similar, nil
+}
+
+// reindex puts song id as stored into the index of similar songs. When
+// the song cannot be read the index lags behind until it is rebuilt.
+func (s *SongService) reindex(ctx context.Context, id int) {
+	song, err := s.songProvider.Song(ctx, id)
+	if err != nil {
+		return
+	}
+
+	s.similar.Put(song)
+}
+
+// canonicalKey returns the canonical key of song id after update, reading
+// the name the update leaves unchanged from the song.
+func (s *SongService) canonicalKey(ctx context.Context, id int, update models.UpdateSongData) (string, error) {
+	var group, song string
+
+	if update.Group == nil || update.Song == nil {
+		current, err := s.songProvider.Song(ctx, id)
+		if err != nil {
+			return "", err
+		}
+
+		group, song = current.Group, current.Song
+	}
+
+	if update.Group != nil {
+		group = *update.Group
+	}
+
+	if update.Song != nil {
+		song = *update.Song
+	}
+
+	return dedup.CanonicalKey(group, song), nil
+}
+
+// authorize is the service-level counterpart of the rbac middleware, so the
+// rules hold for every caller and not only for the routes that check them.
+func (s *SongService) authorize(ctx context.Context, perm rbac.Permission) error {
+	p, ok := principal.FromContext(ctx)
+	if !ok || !s.authorizer.Permits(p, perm) {
+		return service.ErrForbidden
+	}
+
+	return nil
+}
+
+func normalizeFilter(filter models.FilterSongData) (models.FilterSongData, error) {
 	if *filter.Group == "" {
 		filter.Group = nil
 	}
@@ -117,43 +1085,54 @@ func (s *SongService) Songs(filter models.FilterSongData) ([]models.SongData, er
 		filter.ReleaseDate = nil
 	}
 
+	if filter.Language != nil && *filter.Language == "" {
+		filter.Language = nil
+	}
+
+	if filter.Query != nil && strings.TrimSpace(*filter.Query) == "" {
+		filter.Query = nil
+	}
+
+	if filter.Language != nil {
+		base, err := locale.Base(*filter.Language)
+		if err != nil {
+			return filter, service.ErrInvalidLanguage
+		}
+
+		filter.Language = &base
+	}
+
 	if filter.ReleaseDate != nil {
 		parsedDate, err := time.Parse("02.01.2006", *filter.ReleaseDate)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, service.ErrInvalidDateFormat)
+			return filter, service.ErrInvalidDateFormat
 		}
 
 		formattedDate := parsedDate.Format("2006-01-02")
 		filter.ReleaseDate = &formattedDate
 	}
 
-	songs, err := s.songProvider.Songs(filter)
-	if err != nil {
-		return nil, err
-	}
-
-	return songs, nil
+	return filter, nil
 }
 
-func (s *SongService) Text(id, verse int) (string, error) {
-	const op = "service/song-service/Text"
-
-	if verse < 1 {
-		return "", fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
+func topWords(top int) (int, error) {
+	if top == 0 {
+		return defaultTopWords, nil
 	}
 
-	text, err := s.songProvider.Text(id)
-	if err != nil {
-		return "", err
+	if top < 0 || top > maxTopWords {
+		return 0, service.ErrInvalidTop
 	}
 
-	verses := splitByVerses(text)
+	return top, nil
+}
 
-	if verse > len(verses) {
-		return "", fmt.Errorf("%s: %w", op, service.ErrInvalidVerseNumber)
-	}
+// fingerprint returns the fingerprint of lyrics, the zero one when they
+// have no words.
+func fingerprint(text string) models.Fingerprint {
+	fp, _ := dedup.Fingerprint(text)
 
-	return verses[verse-1], nil
+	return fp
 }
 
 func isEmptyUpdate(req models.UpdateSongData) bool {
@@ -161,29 +1140,45 @@ func isEmptyUpdate(req models.UpdateSongData) bool {
 		req.ReleaseDate == nil && req.Link == nil && req.Text == nil
 }
 
-func splitByVerses(text string) []string {
-	lines := strings.Split(text, "\n")
+func languages(translations []models.Translation) []string {
+	langs := make([]string, 0, len(translations))
+	for _, t := range translations {
+		langs = append(langs, t.Lang)
+	}
+
+	return langs
+}
+
+func findTranslation(translations []models.Translation, lang string) (models.Translation, bool) {
+	for _, t := range translations {
+		if t.Lang == lang {
+			return t, true
+		}
+	}
 
-	verses := []string{}
-	currentVerse := strings.Builder{}
+	return models.Translation{}, false
+}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			if currentVerse.Len() > 0 {
-				verses = append(verses, currentVerse.String())
-				currentVerse.Reset()
-			}
-		} else {
-			if currentVerse.Len() > 0 {
-				currentVerse.WriteString("\n")
-			}
-			currentVerse.WriteString(line)
+func originalLanguage(translations []models.Translation) string {
+	for _, t := range translations {
+		if t.Original {
+			return t.Lang
 		}
 	}
 
-	if currentVerse.Len() > 0 {
-		verses = append(verses, currentVerse.String())
+	return ""
+}
+
+func verseCountWarning(lang string, verses, originalVerses int) string {
+	return fmt.Sprintf("%s lyrics have %d verses but the original has %d, verse numbers may not match", lang, verses, originalVerses)
+}
+
+func findVerse(sections []models.ChordSection, number int) (models.ChordSection, bool) {
+	for _, section := range sections {
+		if section.Type == lyrics.SectionVerse && section.Number == number {
+			return section, true
+		}
 	}
 
-	return verses
+	return models.ChordSection{}, false
 }
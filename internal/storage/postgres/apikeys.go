@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+type apiKeyRow struct {
+	ID         int            `db:"id"`
+	Name       string         `db:"name"`
+	Prefix     string         `db:"prefix"`
+	Hash       string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	AllowedIPs pq.StringArray `db:"allowed_ips"`
+	ExpiresAt  *time.Time     `db:"expires_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+	LastUsedAt *time.Time     `db:"last_used_at"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+}
+
+func (r apiKeyRow) model() models.APIKey {
+	return models.APIKey{
+		ID:         r.ID,
+		Name:       r.Name,
+		Prefix:     r.Prefix,
+		Hash:       r.Hash,
+		Scopes:     r.Scopes,
+		AllowedIPs: r.AllowedIPs,
+		ExpiresAt:  r.ExpiresAt,
+		CreatedAt:  r.CreatedAt,
+		LastUsedAt: r.LastUsedAt,
+		RevokedAt:  r.RevokedAt,
+	}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, allowed_ips, expires_at, created_at, last_used_at, revoked_at`
+
+func (s *Storage) SaveAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	const op = "storage.postgres.SaveAPIKey"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (name, prefix, key_hash, scopes, allowed_ips, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING %s
+	`, apiKeysTable, apiKeyColumns,
+	)
+
+	var row apiKeyRow
+	err := s.db.QueryRowxContext(ctx, query,
+		key.Name, key.Prefix, key.Hash, pq.StringArray(key.Scopes), pq.StringArray(key.AllowedIPs), key.ExpiresAt,
+	).StructScan(&row)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return row.model(), nil
+}
+
+func (s *Storage) APIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	const op = "storage.postgres.APIKeyByHash"
+
+	var row apiKeyRow
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE key_hash = $1`, apiKeyColumns, apiKeysTable)
+
+	if err := s.db.GetContext(ctx, &row, query, hash); err != nil {
+		if err == sql.ErrNoRows {
+			return models.APIKey{}, fmt.Errorf("%s: %w", op, storage.ErrKeyNotFound)
+		}
+
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return row.model(), nil
+}
+
+func (s *Storage) APIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "storage.postgres.APIKeys"
+
+	rows := make([]apiKeyRow, 0)
+	query := fmt.Sprintf(`SELECT %s FROM %s ORDER BY id`, apiKeyColumns, apiKeysTable)
+
+	if err := s.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.model())
+	}
+
+	return keys, nil
+}
+
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int) error {
+	const op = "storage.postgres.RevokeAPIKey"
+
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, apiKeysTable)
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrKeyNotFound)
+	}
+
+	return nil
+}
+
+// TouchAPIKey records the key as used. To avoid a write on every request the
+// timestamp is only moved forward once a minute.
+func (s *Storage) TouchAPIKey(ctx context.Context, id int) error {
+	const op = "storage.postgres.TouchAPIKey"
+
+	query := fmt.Sprintf(`
+		UPDATE %s SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, apiKeysTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
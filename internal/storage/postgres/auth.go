@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+)
+
+func (s *Storage) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	const op = "storage.postgres.SaveRefreshToken"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (token_hash, subject, expires_at)
+		VALUES ($1, $2, $3)
+	`, refreshTokensTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, token.Hash, token.Subject, token.ExpiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) RefreshToken(ctx context.Context, hash string) (models.RefreshToken, error) {
+	const op = "storage.postgres.RefreshToken"
+
+	var token models.RefreshToken
+	query := fmt.Sprintf(`SELECT token_hash, subject, expires_at, revoked FROM %s WHERE token_hash = $1`, refreshTokensTable)
+
+	if err := s.db.GetContext(ctx, &token, query, hash); err != nil {
+		if err == sql.ErrNoRows {
+			return models.RefreshToken{}, fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+		}
+
+		return models.RefreshToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// RevokeRefreshToken marks the token revoked. It returns ErrTokenNotFound if
+// the token does not exist or was already revoked, so concurrent refreshes
+// with the same token cannot both succeed.
+func (s *Storage) RevokeRefreshToken(ctx context.Context, hash string) error {
+	const op = "storage.postgres.RevokeRefreshToken"
+
+	query := fmt.Sprintf(`UPDATE %s SET revoked = TRUE WHERE token_hash = $1 AND NOT revoked`, refreshTokensTable)
+
+	result, err := s.db.ExecContext(ctx, query, hash)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTokenNotFound)
+	}
+
+	return nil
+}
+
+func (s *Storage) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	const op = "storage.postgres.RevokeAccessToken"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`, revokedTokensTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, jti, expiresAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	const op = "storage.postgres.IsAccessTokenRevoked"
+
+	var revoked bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE jti = $1)`, revokedTokensTable)
+
+	if err := s.db.GetContext(ctx, &revoked, query, jti); err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revoked, nil
+}
+
+// PurgeExpiredTokens drops revocation entries and refresh tokens that can no
+// longer be presented because they have expired.
+func (s *Storage) PurgeExpiredTokens(ctx context.Context) error {
+	const op = "storage.postgres.PurgeExpiredTokens"
+
+	for _, table := range []string{revokedTokensTable, refreshTokensTable} {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < NOW()`, table)
+
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
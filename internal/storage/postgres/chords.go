@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"effective_mobile/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// SaveChords creates or replaces the ChordPro chord sheet of song id.
+func (s *Storage) SaveChords(ctx context.Context, id int, chordPro string) error {
+	const op = "storage.postgres.SaveChords"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (song_id, chordpro)
+		VALUES ($1, $2)
+		ON CONFLICT (song_id) DO UPDATE
+		SET chordpro = EXCLUDED.chordpro, updated_at = NOW()
+	`, songChordsTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, id, chordPro); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23503" {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Chords returns the ChordPro chord sheet of song id.
+func (s *Storage) Chords(ctx context.Context, id int) (string, error) {
+	const op = "storage.postgres.Chords"
+
+	query := fmt.Sprintf(`
+		SELECT c.chordpro
+		FROM %s s
+		LEFT JOIN %s c ON c.song_id = s.id
+		WHERE s.id = $1
+	`, songsTable, songChordsTable,
+	)
+
+	var chordPro sql.NullString
+
+	err := s.db.GetContext(ctx, &chordPro, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !chordPro.Valid {
+		return "", fmt.Errorf("%s: %w", op, storage.ErrChordsNotFound)
+	}
+
+	return chordPro.String, nil
+}
+
+// DeleteChords deletes the chord sheet of song id.
+func (s *Storage) DeleteChords(ctx context.Context, id int) error {
+	const op = "storage.postgres.DeleteChords"
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1`, songChordsTable)
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrChordsNotFound)
+	}
+
+	return nil
+}
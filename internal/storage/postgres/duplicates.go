@@ -0,0 +1,383 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// Song returns song id.
+func (s *Storage) Song(ctx context.Context, id int) (models.SongData, error) {
+	const op = "storage.postgres.Song"
+
+	query := fmt.Sprintf(`SELECT %s, coalesce(canonical_key, '') AS canonical_key FROM %s WHERE id = $1`, songColumns, songsTable)
+
+	var song models.SongData
+	if err := s.db.GetContext(ctx, &song, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return models.SongData{}, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+// SetDuplicateKeys stores the canonical key of song id and the fingerprint
+// of its lyrics.
+func (s *Storage) SetDuplicateKeys(ctx context.Context, id int, key string, fp models.Fingerprint) error {
+	const op = "storage.postgres.SetDuplicateKeys"
+
+	query := fmt.Sprintf(`UPDATE %s SET canonical_key = $1, lyrics_minhash = $2, lyrics_bands = $3 WHERE id = $4`, songsTable)
+
+	minHash, bands := fingerprintValues(&fp)
+
+	result, err := s.db.ExecContext(ctx, query, key, minHash, bands, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	return nil
+}
+
+// SongsBatch returns up to limit songs with IDs greater than afterID, in ID
+// order, only of those without a canonical key when missingOnly is set.
+func (s *Storage) SongsBatch(ctx context.Context, afterID, limit int, missingOnly bool) ([]models.SongData, error) {
+	const op = "storage.postgres.SongsBatch"
+
+	condition := ""
+	if missingOnly {
+		condition = " AND canonical_key IS NULL"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, "group", song, coalesce(lyrics, '') AS lyrics,
+			coalesce(to_char(release_date, 'YYYY-MM-DD'), '') AS release_date,
+			coalesce(language, '') AS language
+		FROM %s
+		WHERE id > $1%s
+		ORDER BY id
+		LIMIT $2
+	`, songsTable, condition,
+	)
+
+	songs := make([]models.SongData, 0, limit)
+	if err := s.db.SelectContext(ctx, &songs, query, afterID, limit); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+type duplicateRow struct {
+	FirstID     int     `db:"first_id"`
+	FirstGroup  string  `db:"first_group"`
+	FirstSong   string  `db:"first_song"`
+	SecondID    int     `db:"second_id"`
+	SecondGroup string  `db:"second_group"`
+	SecondSong  string  `db:"second_song"`
+	SameKey     bool    `db:"same_key"`
+	Similarity  float64 `db:"similarity"`
+}
+
+// Duplicates returns up to limit pairs of songs with the same canonical key
+// or with lyrics at least threshold similar, pairs with the same key first
+// and then by similarity. Candidates with similar lyrics are the songs that
+// share a band of their fingerprints, so the whole catalog is not compared
+// pairwise; their similarity is the share of equal MinHash values.
+func (s *Storage) Duplicates(ctx context.Context, threshold float64, limit int) ([]models.DuplicatePair, error) {
+	const op = "storage.postgres.Duplicates"
+
+	query := fmt.Sprintf(`
+		WITH candidates AS (
+			SELECT a.id AS first_id, b.id AS second_id
+			FROM %[1]s a
+			JOIN %[1]s b ON b.canonical_key = a.canonical_key AND b.id > a.id
+			WHERE a.canonical_key <> ''
+			UNION
+			SELECT a.id, b.id
+			FROM %[1]s a
+			JOIN %[1]s b ON b.lyrics_bands && a.lyrics_bands AND b.id > a.id
+		), pairs AS (
+			SELECT a.id AS first_id, a."group" AS first_group, a.song AS first_song,
+				b.id AS second_id, b."group" AS second_group, b.song AS second_song,
+				coalesce(a.canonical_key = b.canonical_key, false) AS same_key,
+				coalesce((
+					SELECT count(*) FILTER (WHERE x = y)
+					FROM unnest(a.lyrics_minhash, b.lyrics_minhash) AS m(x, y)
+				)::float8 / nullif(cardinality(a.lyrics_minhash), 0), 0) AS similarity
+			FROM candidates c
+			JOIN %[1]s a ON a.id = c.first_id
+			JOIN %[1]s b ON b.id = c.second_id
+		)
+		SELECT first_id, first_group, first_song, second_id, second_group, second_song, same_key, similarity
+		FROM pairs
+		WHERE same_key OR similarity >= $1
+		ORDER BY same_key DESC, similarity DESC, first_id, second_id
+		LIMIT $2
+	`, songsTable,
+	)
+
+	var rows []duplicateRow
+	if err := s.db.SelectContext(ctx, &rows, query, threshold, limit); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	pairs := make([]models.DuplicatePair, 0, len(rows))
+	for _, row := range rows {
+		pairs = append(pairs, models.DuplicatePair{
+			First:            models.SongRef{ID: row.FirstID, Group: row.FirstGroup, Song: row.FirstSong},
+			Second:           models.SongRef{ID: row.SecondID, Group: row.SecondGroup, Song: row.SecondSong},
+			SameKey:          row.SameKey,
+			LyricsSimilarity: row.Similarity,
+		})
+	}
+
+	return pairs, nil
+}
+
+// snapshotColumn builds the models.SongSnapshot of the song row s as JSON.
+var snapshotColumn = fmt.Sprintf(`jsonb_build_object(
+		'group', s."group",
+		'song', s.song,
+		'releaseDate', s.release_date,
+		'text', s.lyrics,
+		'link', s.link,
+		'language', s.language,
+		'translations', (
+			SELECT jsonb_agg(jsonb_build_object('lang', l.lang, 'original', l.original, 'text', l.lyrics) ORDER BY l.lang)
+			FROM %s l
+			WHERE l.song_id = s.id
+		),
+		'chordpro', (SELECT c.chordpro FROM %s c WHERE c.song_id = s.id)
+	)`, songLyricsTable, songChordsTable)
+
+// MergeSongs merges song sourceID into song targetID and deletes it. The
+// target keeps what it has and takes from the source what it lacks: the
+// release date, the link, the lyrics with their synced lines, language
+// and fingerprint, the language versions it has no lyrics in and the chord
+// sheet. The views of the source are added to the target. The state of
+// both songs before the merge is kept as revisions made by actor. It
+// returns the merged target.
+func (s *Storage) MergeSongs(ctx context.Context, sourceID, targetID int, actor string) (models.SongData, error) {
+	const op = "storage.postgres.MergeSongs"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Rows are locked in ID order, so concurrent merges of the same songs
+	// do not deadlock.
+	var locked []int
+	lockQuery := fmt.Sprintf(`SELECT id FROM %s WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, songsTable)
+	if err := tx.SelectContext(ctx, &locked, lockQuery, sourceID, targetID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(locked) != 2 {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	revisionsQuery := fmt.Sprintf(`
+		INSERT INTO %s (song_id, action, related_song_id, actor, snapshot)
+		SELECT s.id,
+			CASE WHEN s.id = $1::int THEN '%s' ELSE '%s' END,
+			CASE WHEN s.id = $1::int THEN $2::int ELSE $1::int END,
+			$3::text,
+			%s
+		FROM %s s
+		WHERE s.id IN ($1::int, $2::int)
+	`, songRevisionsTable, models.RevisionMergedInto, models.RevisionMerge, snapshotColumn, songsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, revisionsQuery, sourceID, targetID, actor); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The lyrics of the source are taken only by a target without any, and
+	// everything derived from them moves along.
+	var takeLyrics bool
+	takeQuery := fmt.Sprintf(`
+		SELECT coalesce(t.lyrics, '') = '' AND coalesce(s.lyrics, '') <> ''
+		FROM %[1]s t, %[1]s s
+		WHERE t.id = $1 AND s.id = $2
+	`, songsTable,
+	)
+
+	if err := tx.GetContext(ctx, &takeLyrics, takeQuery, targetID, sourceID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mergeQuery := fmt.Sprintf(`
+		UPDATE %[1]s t SET
+			release_date = coalesce(t.release_date, s.release_date),
+			link = CASE WHEN coalesce(t.link, '') = '' THEN s.link ELSE t.link END,
+			lyrics = CASE WHEN $3 THEN s.lyrics ELSE t.lyrics END,
+			language = CASE WHEN $3 THEN s.language ELSE t.language END,
+			language_confidence = CASE WHEN $3 THEN s.language_confidence ELSE t.language_confidence END,
+			lyrics_minhash = CASE WHEN $3 THEN s.lyrics_minhash ELSE t.lyrics_minhash END,
+			lyrics_bands = CASE WHEN $3 THEN s.lyrics_bands ELSE t.lyrics_bands END
+		FROM %[1]s s
+		WHERE t.id = $1 AND s.id = $2
+	`, songsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, mergeQuery, targetID, sourceID, takeLyrics); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if takeLyrics {
+		clearQuery := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1`, lyricLinesTable)
+		if _, err := tx.ExecContext(ctx, clearQuery, targetID); err != nil {
+			return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		linesQuery := fmt.Sprintf(`UPDATE %s SET song_id = $1 WHERE song_id = $2`, lyricLinesTable)
+		if _, err := tx.ExecContext(ctx, linesQuery, targetID, sourceID); err != nil {
+			return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// The original marker of the source only moves with its lyrics and
+	// when the target has no original of its own.
+	translationsQuery := fmt.Sprintf(`
+		UPDATE %[1]s SET song_id = $1
+		WHERE song_id = $2
+			AND lang NOT IN (SELECT lang FROM %[1]s WHERE song_id = $1)
+			AND (NOT original OR ($3 AND NOT EXISTS (SELECT 1 FROM %[1]s WHERE song_id = $1 AND original)))
+	`, songLyricsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, translationsQuery, targetID, sourceID, takeLyrics); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	chordsQuery := fmt.Sprintf(`
+		UPDATE %[1]s SET song_id = $1
+		WHERE song_id = $2 AND NOT EXISTS (SELECT 1 FROM %[1]s WHERE song_id = $1)
+	`, songChordsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, chordsQuery, targetID, sourceID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	viewsQuery := fmt.Sprintf(`
+		INSERT INTO %[1]s (song_id, views)
+		SELECT $1, views FROM %[1]s WHERE song_id = $2
+		ON CONFLICT (song_id) DO UPDATE SET views = %[1]s.views + EXCLUDED.views
+	`, songViewsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, viewsQuery, targetID, sourceID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	deleteQuery := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, songsTable)
+	if _, err := tx.ExecContext(ctx, deleteQuery, sourceID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var merged models.SongData
+	songQuery := fmt.Sprintf(`SELECT %s FROM %s WHERE id = $1`, songColumns, songsTable)
+	if err := tx.GetContext(ctx, &merged, songQuery, targetID); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SongData{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return merged, nil
+}
+
+type revisionRow struct {
+	ID            int           `db:"id"`
+	SongID        int           `db:"song_id"`
+	Action        string        `db:"action"`
+	RelatedSongID sql.NullInt64 `db:"related_song_id"`
+	Actor         string        `db:"actor"`
+	CreatedAt     time.Time     `db:"created_at"`
+	Snapshot      []byte        `db:"snapshot"`
+}
+
+// Revisions returns the revisions of song id, oldest first. A song merged
+// into another still has its revisions; only an ID that never had any and
+// is not a song is reported as storage.ErrSongNotFound.
+func (s *Storage) Revisions(ctx context.Context, id int) ([]models.Revision, error) {
+	const op = "storage.postgres.Revisions"
+
+	query := fmt.Sprintf(`
+		SELECT id, song_id, action, related_song_id, actor, created_at, snapshot
+		FROM %s
+		WHERE song_id = $1
+		ORDER BY id
+	`, songRevisionsTable,
+	)
+
+	var rows []revisionRow
+	if err := s.db.SelectContext(ctx, &rows, query, id); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(rows) == 0 {
+		var exists bool
+		existsQuery := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1)`, songsTable)
+		if err := s.db.GetContext(ctx, &exists, existsQuery, id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if !exists {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+	}
+
+	revisions := make([]models.Revision, 0, len(rows))
+	for _, row := range rows {
+		revision := models.Revision{
+			ID:            row.ID,
+			SongID:        row.SongID,
+			Action:        row.Action,
+			RelatedSongID: int(row.RelatedSongID.Int64),
+			Actor:         row.Actor,
+			CreatedAt:     row.CreatedAt,
+		}
+
+		if err := json.Unmarshal(row.Snapshot, &revision.Snapshot); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// fingerprintValues returns the column values of fp, NULLs for lyrics
+// without words.
+func fingerprintValues(fp *models.Fingerprint) (interface{}, interface{}) {
+	if fp == nil || len(fp.MinHash) == 0 {
+		return nil, nil
+	}
+
+	return pq.Array(fp.MinHash), pq.Array(fp.Bands)
+}
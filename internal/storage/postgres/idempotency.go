@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+)
+
+const idempotencyColumns = `client, key, request_hash, status, content_type, body, expires_at`
+
+// ReserveIdempotencyKey stores record as in progress unless an unexpired
+// record with the same client and key exists. It returns the record now
+// stored and whether it was created by this call.
+func (s *Storage) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) (models.IdempotencyRecord, bool, error) {
+	const op = "storage.postgres.ReserveIdempotencyKey"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (client, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (client, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL,
+			created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE %s.expires_at < NOW()
+		RETURNING %s
+	`, idempotencyTable, idempotencyTable, idempotencyColumns,
+	)
+
+	rows, err := s.db.QueryxContext(ctx, query, record.Client, record.Key, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var stored models.IdempotencyRecord
+		if err := rows.StructScan(&stored); err != nil {
+			return models.IdempotencyRecord{}, false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return stored, true, nil
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var existing models.IdempotencyRecord
+	query = fmt.Sprintf(`SELECT %s FROM %s WHERE client = $1 AND key = $2`, idempotencyColumns, idempotencyTable)
+
+	if err := s.db.GetContext(ctx, &existing, query, record.Client, record.Key); err != nil {
+		return models.IdempotencyRecord{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return existing, false, nil
+}
+
+func (s *Storage) CompleteIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	const op = "storage.postgres.CompleteIdempotencyKey"
+
+	query := fmt.Sprintf(`
+		UPDATE %s SET status = $3, content_type = $4, body = $5
+		WHERE client = $1 AND key = $2
+	`, idempotencyTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, record.Client, record.Key, record.Status, record.ContentType, record.Body); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, client, key string) error {
+	const op = "storage.postgres.ReleaseIdempotencyKey"
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE client = $1 AND key = $2`, idempotencyTable)
+
+	if _, err := s.db.ExecContext(ctx, query, client, key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PurgeIdempotencyKeys(ctx context.Context) error {
+	const op = "storage.postgres.PurgeIdempotencyKeys"
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE expires_at < NOW()`, idempotencyTable)
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
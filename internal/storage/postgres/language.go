@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+)
+
+// SetLanguage stores the language detected in the lyrics of song id.
+func (s *Storage) SetLanguage(ctx context.Context, id int, detected models.DetectedLanguage) error {
+	const op = "storage.postgres.SetLanguage"
+
+	query := fmt.Sprintf(`UPDATE %s SET language = $1, language_confidence = $2 WHERE id = $3`, songsTable)
+
+	language, confidence := languageValues(detected)
+
+	result, err := s.db.ExecContext(ctx, query, language, confidence, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	return nil
+}
+
+// LyricsBatch returns the IDs and lyrics of up to limit songs with IDs
+// greater than afterID, in ID order, only of those without a detected
+// language when missingOnly is set. Songs without lyrics are skipped.
+func (s *Storage) LyricsBatch(ctx context.Context, afterID, limit int, missingOnly bool) ([]models.SongData, error) {
+	const op = "storage.postgres.LyricsBatch"
+
+	condition := ""
+	if missingOnly {
+		condition = " AND language IS NULL"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, lyrics
+		FROM %s
+		WHERE id > $1 AND lyrics IS NOT NULL%s
+		ORDER BY id
+		LIMIT $2
+	`, songsTable, condition,
+	)
+
+	songs := make([]models.SongData, 0, limit)
+	if err := s.db.SelectContext(ctx, &songs, query, afterID, limit); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+// languageValues returns the column values of detected, NULLs when no
+// language was detected.
+func languageValues(detected models.DetectedLanguage) (interface{}, interface{}) {
+	if detected.Lang == "" {
+		return nil, nil
+	}
+
+	return detected.Lang, detected.Confidence
+}
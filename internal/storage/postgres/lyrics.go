@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// SaveSyncedLyrics replaces the timed lines of song id and sets its plain
+// lyrics to text, along with their detected language and fingerprint, in
+// one transaction.
+func (s *Storage) SaveSyncedLyrics(ctx context.Context, id int, lines []models.TimedLine, text string, detected models.DetectedLanguage, fp models.Fingerprint) error {
+	const op = "storage.postgres.SaveSyncedLyrics"
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET lyrics = $1, language = $2, language_confidence = $3, lyrics_minhash = $4, lyrics_bands = $5
+		WHERE id = $6
+	`, songsTable,
+	)
+
+	language, confidence := languageValues(detected)
+	minHash, bands := fingerprintValues(&fp)
+
+	result, err := tx.ExecContext(ctx, query, text, language, confidence, minHash, bands, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	query = fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1`, lyricLinesTable)
+
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	offsets := make([]int64, len(lines))
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		offsets[i] = int64(line.OffsetMS)
+		texts[i] = line.Text
+	}
+
+	query = fmt.Sprintf(`
+		INSERT INTO %s (song_id, position, offset_ms, line)
+		SELECT $1, u.position, u.offset_ms, u.line
+		FROM unnest($2::INTEGER[], $3::TEXT[]) WITH ORDINALITY AS u(offset_ms, line, position)
+	`, lyricLinesTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, query, id, pq.Array(offsets), pq.Array(texts)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Lyrics returns the plain lyrics of song id and its timed lines in order.
+// Lines is empty when the song has no synced lyrics.
+func (s *Storage) Lyrics(ctx context.Context, id int) (models.Lyrics, error) {
+	const op = "storage.postgres.Lyrics"
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(s.lyrics, '') AS lyrics, l.offset_ms, l.line
+		FROM %s s
+		LEFT JOIN %s l ON l.song_id = s.id
+		WHERE s.id = $1
+		ORDER BY l.position
+	`, songsTable, lyricLinesTable,
+	)
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var lyrics models.Lyrics
+	found := false
+
+	for rows.Next() {
+		var offset sql.NullInt64
+		var line sql.NullString
+
+		if err := rows.Scan(&lyrics.Text, &offset, &line); err != nil {
+			return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		found = true
+
+		if offset.Valid {
+			lyrics.Lines = append(lyrics.Lines, models.TimedLine{
+				OffsetMS: int(offset.Int64),
+				Text:     line.String,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !found {
+		return models.Lyrics{}, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	return lyrics, nil
+}
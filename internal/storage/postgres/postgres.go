@@ -1,14 +1,17 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"effective_mobile/internal/domain/models"
 	"effective_mobile/internal/storage"
 	"fmt"
 	"strings"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 type Storage struct {
@@ -18,14 +21,24 @@ type Storage struct {
 func New(port int, host, username, dbname, password, sslMode string) (*Storage, error) {
 	const op = "storage.postgres.New"
 
-	db, err := sqlx.Open("postgres",
+	// Every statement gets its own span under the span of the caller's ctx.
+	sqlDB, err := otelsql.Open("postgres",
 		fmt.Sprintf("host=%s port =%d user=%s dbname=%s password=%s sslmode=%s",
-			host, port, username, dbname, password, sslMode))
+			host, port, username, dbname, password, sslMode),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
 
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	db := sqlx.NewDb(sqlDB, "postgres")
+
 	err = db.Ping()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
@@ -34,18 +47,28 @@ func New(port int, host, username, dbname, password, sslMode string) (*Storage,
 	return &Storage{db: db}, nil
 }
 
+// DB exposes the underlying connection pool for migrations.
+func (s *Storage) DB() *sql.DB {
+	return s.db.DB
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 func (s *Storage) Close() error {
 	return s.db.Close()
 }
 
-func (s *Storage) SaveSong(songData models.SongData) (int, error) {
+func (s *Storage) SaveSong(ctx context.Context, songData models.SongData) (int, error) {
 	const op = "storage.postgres.SaveSong"
 
 	var id int
 
 	query := fmt.Sprintf(`
-		INSERT INTO %s ("group", song, release_date, lyrics, link) 
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO %s ("group", song, release_date, lyrics, link, language, language_confidence,
+			canonical_key, lyrics_minhash, lyrics_bands)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`, songsTable,
 	)
@@ -57,7 +80,11 @@ func (s *Storage) SaveSong(songData models.SongData) (int, error) {
 		releaseDate = songData.ReleaseDate
 	}
 
-	if err := s.db.QueryRowx(query, songData.Group, songData.Song, releaseDate, songData.Text, songData.Link).Scan(&id); err != nil {
+	language, confidence := languageValues(models.DetectedLanguage{Lang: songData.Language, Confidence: songData.LanguageConfidence})
+	minHash, bands := fingerprintValues(songData.Fingerprint)
+
+	if err := s.db.QueryRowxContext(ctx, query, songData.Group, songData.Song, releaseDate, songData.Text, songData.Link, language, confidence,
+		songData.CanonicalKey, minHash, bands).Scan(&id); err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			return 0, storage.ErrSongExists
 		}
@@ -68,41 +95,69 @@ func (s *Storage) SaveSong(songData models.SongData) (int, error) {
 	return id, nil
 }
 
-func (s *Storage) Songs(filter models.FilterSongData) ([]models.SongData, error) {
+func (s *Storage) Songs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error) {
 	const op = "storage.postgres.Songs"
 
 	query := strings.Builder{}
-	query.WriteString(fmt.Sprintf("SELECT id, \"group\", song, release_date, lyrics, link FROM %s WHERE 1=1", songsTable))
+	query.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", songColumns, songsTable))
 
-	args := make([]interface{}, 0)
-	argId := 1
+	conditions, args := filterConditions(filter)
+	query.WriteString(conditions)
+	argId := len(args) + 1
 
-	if filter.Group != nil {
-		query.WriteString(fmt.Sprintf(" AND \"group\"=$%d", argId))
-		args = append(args, *filter.Group)
+	// Search results come best match first.
+	if filter.Query != nil {
+		query.WriteString(fmt.Sprintf(" ORDER BY ts_rank(search, %s) DESC, id DESC", searchQuery(argId)))
+		args = append(args, *filter.Query)
 		argId++
+	} else {
+		query.WriteString(" ORDER BY id DESC")
 	}
 
-	if filter.Song != nil {
-		query.WriteString(fmt.Sprintf(" AND song=$%d", argId))
-		args = append(args, *filter.Song)
-		argId++
+	offset := (filter.Page - 1) * filter.PerPage
+	query.WriteString(fmt.Sprintf(" LIMIT $%d OFFSET $%d", argId, argId+1))
+	args = append(args, filter.PerPage, offset)
+
+	songs, err := s.selectSongs(ctx, query.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if filter.ReleaseDate != nil {
-		query.WriteString(fmt.Sprintf(" AND release_date=$%d", argId))
-		args = append(args, *filter.ReleaseDate)
-		argId++
+	if len(songs) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
 	}
 
-	offset := (filter.Page - 1) * filter.PerPage
-	query.WriteString(fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", argId, argId+1))
-	args = append(args, filter.PerPage, offset)
+	return songs, nil
+}
+
+// AllSongs returns every song matching filter, ignoring paging.
+func (s *Storage) AllSongs(ctx context.Context, filter models.FilterSongData) ([]models.SongData, error) {
+	const op = "storage.postgres.AllSongs"
+
+	query := strings.Builder{}
+	query.WriteString(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", songColumns, songsTable))
 
-	rows, err := s.db.Queryx(query.String(), args...)
+	conditions, args := filterConditions(filter)
+	query.WriteString(conditions)
+	query.WriteString(" ORDER BY id")
+
+	songs, err := s.selectSongs(ctx, query.String(), args...)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+
+	return songs, nil
+}
+
+// songColumns are the columns of songs scanned into models.SongData.
+const songColumns = `id, "group", song, release_date, lyrics, link,
+	coalesce(language, '') AS language, coalesce(language_confidence, 0) AS language_confidence`
+
+func (s *Storage) selectSongs(ctx context.Context, query string, args ...interface{}) ([]models.SongData, error) {
+	rows, err := s.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	songs := make([]models.SongData, 0)
@@ -110,29 +165,72 @@ func (s *Storage) Songs(filter models.FilterSongData) ([]models.SongData, error)
 		var song models.SongData
 		err := rows.StructScan(&song)
 		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
+			return nil, err
 		}
 		songs = append(songs, song)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
 
-	if len(songs) == 0 {
-		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	return songs, nil
+}
+
+// filterConditions returns the conditions of filter on the songs table.
+// Names are compared by their search keys, so either script matches.
+func filterConditions(filter models.FilterSongData) (string, []interface{}) {
+	query := strings.Builder{}
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.Group != nil {
+		query.WriteString(fmt.Sprintf(" AND group_key=search_key($%d)", argId))
+		args = append(args, *filter.Group)
+		argId++
 	}
 
-	return songs, nil
+	if filter.Song != nil {
+		query.WriteString(fmt.Sprintf(" AND song_key=search_key($%d)", argId))
+		args = append(args, *filter.Song)
+		argId++
+	}
+
+	if filter.ReleaseDate != nil {
+		query.WriteString(fmt.Sprintf(" AND release_date=$%d", argId))
+		args = append(args, *filter.ReleaseDate)
+		argId++
+	}
+
+	if filter.Language != nil {
+		query.WriteString(fmt.Sprintf(" AND language=$%d", argId))
+		args = append(args, *filter.Language)
+		argId++
+	}
+
+	if filter.Query != nil {
+		query.WriteString(fmt.Sprintf(" AND search @@ %s", searchQuery(argId)))
+		args = append(args, *filter.Query)
+	}
+
+	return query.String(), args
+}
+
+// searchQuery is the full-text query of the search text in parameter arg:
+// every word of its search key, each as a prefix, so results show up
+// while the last word is being typed. A text without letters or digits
+// gives NULL, which matches nothing.
+func searchQuery(arg int) string {
+	return fmt.Sprintf(`to_tsquery('simple', replace(nullif(search_key($%d::text), ''), ' ', ':* & ') || ':*')`, arg)
 }
 
-func (s *Storage) Text(id int) (string, error) {
+func (s *Storage) Text(ctx context.Context, id int) (string, error) {
 	const op = "storage.postgres.Text"
 
 	var text string
 	query := fmt.Sprintf(`SELECT lyrics FROM %s WHERE id = $1`, songsTable)
 
-	err := s.db.Get(&text, query, id)
+	err := s.db.GetContext(ctx, &text, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
@@ -144,7 +242,7 @@ func (s *Storage) Text(id int) (string, error) {
 	return text, nil
 }
 
-func (s *Storage) UpdateSong(id int, updateSong models.UpdateSongData) error {
+func (s *Storage) UpdateSong(ctx context.Context, id int, updateSong models.UpdateSongData) error {
 	const op = "storage.postgres.UpdateSong"
 
 	setValues := make([]string, 0)
@@ -181,10 +279,37 @@ func (s *Storage) UpdateSong(id int, updateSong models.UpdateSongData) error {
 		argId++
 	}
 
+	if updateSong.Language != nil {
+		language, confidence := languageValues(*updateSong.Language)
+
+		setValues = append(setValues, fmt.Sprintf("language=$%d, language_confidence=$%d", argId, argId+1))
+		args = append(args, language, confidence)
+		argId += 2
+	}
+
+	if updateSong.CanonicalKey != nil {
+		setValues = append(setValues, fmt.Sprintf("canonical_key=$%d", argId))
+		args = append(args, *updateSong.CanonicalKey)
+		argId++
+	}
+
+	if updateSong.Fingerprint != nil {
+		minHash, bands := fingerprintValues(updateSong.Fingerprint)
+
+		setValues = append(setValues, fmt.Sprintf("lyrics_minhash=$%d, lyrics_bands=$%d", argId, argId+1))
+		args = append(args, minHash, bands)
+		argId += 2
+	}
+
 	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id=$%d`, songsTable, strings.Join(setValues, ", "), argId)
+
+	// New plain lyrics replace the synced ones, which would no longer match.
+	if updateSong.Text != nil {
+		query = fmt.Sprintf(`WITH cleared AS (DELETE FROM %s WHERE song_id=$%d) %s`, lyricLinesTable, argId, query)
+	}
 	args = append(args, id)
 
-	result, err := s.db.Exec(query, args...)
+	result, err := s.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			return storage.ErrSongExists
@@ -205,12 +330,12 @@ func (s *Storage) UpdateSong(id int, updateSong models.UpdateSongData) error {
 	return nil
 }
 
-func (s *Storage) DeleteSong(id int) error {
+func (s *Storage) DeleteSong(ctx context.Context, id int) error {
 	const op = "storage.postgres.DeleteSong"
 
 	query := fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, songsTable)
 
-	result, err := s.db.Exec(query, id)
+	result, err := s.db.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IncrementQuota counts one request of client in class for day and returns
+// the number of requests counted so far that day.
+func (s *Storage) IncrementQuota(ctx context.Context, client, class string, day time.Time) (int, error) {
+	const op = "storage.postgres.IncrementQuota"
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (client, class, day, count)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (client, class, day) DO UPDATE SET count = %s.count + 1
+		RETURNING count
+	`, quotasTable, quotasTable,
+	)
+
+	var count int
+	if err := s.db.QueryRowxContext(ctx, query, client, class, day.Format("2006-01-02")).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (s *Storage) PurgeQuotas(ctx context.Context, before time.Time) error {
+	const op = "storage.postgres.PurgeQuotas"
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE day < $1`, quotasTable)
+
+	if _, err := s.db.ExecContext(ctx, query, before.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+
+	"github.com/lib/pq"
+)
+
+// statsCTE splits the lyrics of the songs matching the filter conditions
+// into words the way wordstats.Words does: marker lines such as [Chorus]
+// are dropped, words are lowercased and apostrophes inside them are kept.
+// counts holds the number of words and non-blank lines of every song.
+const statsCTE = `
+	WITH filtered AS (
+		SELECT id, "group", release_date,
+			regexp_replace(coalesce(lyrics, ''), '^[ \t]*\[[^]\n]*\][ \t]*$', '', 'gn') AS lyrics
+		FROM %s
+		WHERE 1=1%s
+	),
+	words AS (
+		SELECT f.id, f."group", btrim(replace(w.word, '’', ''''), '''') AS word
+		FROM filtered f, regexp_split_to_table(lower(f.lyrics), '[^[:alnum:]''’]+') AS w(word)
+	),
+	counts AS (
+		SELECT f.id, f."group", f.release_date,
+			coalesce(w.words, 0) AS words,
+			(SELECT count(*) FROM regexp_split_to_table(f.lyrics, '\n') AS l(line) WHERE btrim(l.line) <> '') AS lines
+		FROM filtered f
+		LEFT JOIN (SELECT id, count(*) AS words FROM words WHERE word <> '' GROUP BY id) w ON w.id = f.id
+	)
+`
+
+type artistWord struct {
+	Group string `db:"group"`
+	Word  string `db:"word"`
+	Count int    `db:"count"`
+}
+
+// CatalogStats counts the words of the songs matching filter per group and
+// per release year. Every group gets its top most frequent words that are
+// not in stopWords. The queries run in one read-only snapshot, so the parts
+// of the result agree with each other.
+func (s *Storage) CatalogStats(ctx context.Context, filter models.FilterSongData, stopWords []string, top int) (models.CatalogStats, error) {
+	const op = "storage.postgres.CatalogStats"
+
+	conditions, args := filterConditions(filter)
+	with := fmt.Sprintf(statsCTE, songsTable, conditions)
+
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	stats := models.CatalogStats{
+		Artists: []models.ArtistStats{},
+		Years:   []models.YearStats{},
+	}
+
+	artistsQuery := with + `
+		SELECT "group", count(*) AS songs, sum(words) AS words
+		FROM counts
+		GROUP BY "group"
+		ORDER BY "group"
+	`
+
+	if err := tx.SelectContext(ctx, &stats.Artists, artistsQuery, args...); err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	topQuery := with + fmt.Sprintf(`
+		SELECT "group", word, count
+		FROM (
+			SELECT "group", word, count(*) AS count,
+				row_number() OVER (PARTITION BY "group" ORDER BY count(*) DESC, word) AS rank
+			FROM words
+			WHERE word <> '' AND NOT word = ANY($%d::text[])
+			GROUP BY "group", word
+		) ranked
+		WHERE rank <= $%d
+		ORDER BY "group", rank
+	`, len(args)+1, len(args)+2)
+
+	var words []artistWord
+	if err := tx.SelectContext(ctx, &words, topQuery, append(args, pq.Array(stopWords), top)...); err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	yearsQuery := with + `
+		SELECT extract(year FROM release_date)::int AS year,
+			count(*) AS songs,
+			round(avg(words), 1)::float8 AS average_words,
+			round(avg(lines), 1)::float8 AS average_lines
+		FROM counts
+		WHERE release_date IS NOT NULL
+		GROUP BY 1
+		ORDER BY 1
+	`
+
+	if err := tx.SelectContext(ctx, &stats.Years, yearsQuery, args...); err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	topWords := make(map[string][]models.WordCount, len(stats.Artists))
+	for _, w := range words {
+		topWords[w.Group] = append(topWords[w.Group], models.WordCount{Word: w.Word, Count: w.Count})
+	}
+
+	for i := range stats.Artists {
+		stats.Artists[i].TopWords = topWords[stats.Artists[i].Group]
+		if stats.Artists[i].TopWords == nil {
+			stats.Artists[i].TopWords = []models.WordCount{}
+		}
+
+		stats.Songs += stats.Artists[i].Songs
+		stats.Words += stats.Artists[i].Words
+	}
+
+	return stats, nil
+}
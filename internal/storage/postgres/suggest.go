@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+
+	"github.com/lib/pq"
+)
+
+// maxSuggestionIDs is how many songs a suggestion lists at most.
+const maxSuggestionIDs = 10
+
+// suggestMatches are the kinds of match by their rank in the query below.
+var suggestMatches = []string{"prefix", "word", "fuzzy"}
+
+// Suggest returns up to limit group names or song titles, or both when kind
+// is models.SuggestAll, that match the search key of q. Names are grouped
+// by their search key, so spellings in either script come as one
+// suggestion. Names that start with q come first, then those with a word
+// that does, then those spelled similarly, each by popularity.
+func (s *Storage) Suggest(ctx context.Context, q, kind string, limit int) ([]models.Suggestion, error) {
+	const op = "storage.postgres.Suggest"
+
+	// Search keys consist of letters, digits and spaces, so they need no
+	// escaping in LIKE patterns. Short keys are similar to too many names
+	// to be matched fuzzily.
+	query := fmt.Sprintf(`
+		WITH names AS (
+			SELECT '%[3]s' AS type, s."group" AS name, s.group_key AS key, s.id, coalesce(v.views, 0) AS views
+			FROM %[1]s s LEFT JOIN %[2]s v ON v.song_id = s.id
+			WHERE $2::text IN ('%[3]s', '%[5]s')
+				AND (s.group_key LIKE search_key($1::text) || '%%'
+					OR s.group_key LIKE '%% ' || search_key($1::text) || '%%'
+					OR (length(search_key($1::text)) >= 3 AND s.group_key %% search_key($1::text)))
+			UNION ALL
+			SELECT '%[4]s', s.song, s.song_key, s.id, coalesce(v.views, 0)
+			FROM %[1]s s LEFT JOIN %[2]s v ON v.song_id = s.id
+			WHERE $2::text IN ('%[4]s', '%[5]s')
+				AND (s.song_key LIKE search_key($1::text) || '%%'
+					OR s.song_key LIKE '%% ' || search_key($1::text) || '%%'
+					OR (length(search_key($1::text)) >= 3 AND s.song_key %% search_key($1::text)))
+		)
+		SELECT type,
+			(array_agg(name ORDER BY views DESC, id))[1] AS text,
+			(array_agg(id ORDER BY views DESC, id))[1:%[6]d] AS ids,
+			sum(views)::bigint AS popularity,
+			min(CASE
+				WHEN key LIKE search_key($1::text) || '%%' THEN 0
+				WHEN key LIKE '%% ' || search_key($1::text) || '%%' THEN 1
+				ELSE 2
+			END) AS match
+		FROM names
+		WHERE search_key($1::text) <> ''
+		GROUP BY type, key
+		ORDER BY match, popularity DESC, max(similarity(key, search_key($1::text))) DESC, text
+		LIMIT $3
+	`, songsTable, songViewsTable, models.SuggestGroup, models.SuggestSong, models.SuggestAll, maxSuggestionIDs,
+	)
+
+	rows, err := s.db.QueryContext(ctx, query, q, kind, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, contextError(ctx, err))
+	}
+	defer rows.Close()
+
+	suggestions := make([]models.Suggestion, 0)
+	for rows.Next() {
+		var suggestion models.Suggestion
+		var ids []int64
+		var match int
+
+		if err := rows.Scan(&suggestion.Type, &suggestion.Text, pq.Array(&ids), &suggestion.Popularity, &match); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, contextError(ctx, err))
+		}
+
+		suggestion.IDs = make([]int, len(ids))
+		for i, id := range ids {
+			suggestion.IDs[i] = int(id)
+		}
+		suggestion.Match = suggestMatches[match]
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, contextError(ctx, err))
+	}
+
+	return suggestions, nil
+}
+
+// AddViews adds counts, views by song ID, to the views of the songs. Songs
+// deleted in the meantime are skipped.
+func (s *Storage) AddViews(ctx context.Context, counts map[int]int64) error {
+	const op = "storage.postgres.AddViews"
+
+	ids := make([]int64, 0, len(counts))
+	views := make([]int64, 0, len(counts))
+	for id, n := range counts {
+		ids = append(ids, int64(id))
+		views = append(views, n)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (song_id, views)
+		SELECT c.id, c.views
+		FROM unnest($1::int[], $2::bigint[]) AS c(id, views)
+		WHERE EXISTS (SELECT 1 FROM %[2]s WHERE id = c.id)
+		ON CONFLICT (song_id) DO UPDATE SET views = %[1]s.views + EXCLUDED.views
+	`, songViewsTable, songsTable,
+	)
+
+	if _, err := s.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(views)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// contextError returns the error of ctx when it is done, as a canceled
+// statement reports a database error instead.
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return err
+}
@@ -1,5 +1,15 @@
 package postgres
 
 var (
-	songsTable = "songs"
+	songsTable         = "songs"
+	refreshTokensTable = "refresh_tokens"
+	revokedTokensTable = "revoked_tokens"
+	apiKeysTable       = "api_keys"
+	quotasTable        = "quotas"
+	idempotencyTable   = "idempotency_keys"
+	lyricLinesTable    = "song_lyric_lines"
+	songLyricsTable    = "song_lyrics"
+	songChordsTable    = "song_chords"
+	songRevisionsTable = "song_revisions"
+	songViewsTable     = "song_views"
 )
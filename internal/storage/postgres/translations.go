@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"effective_mobile/internal/domain/models"
+	"effective_mobile/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+// Translations returns the plain lyrics of song id and its language
+// versions, the original first. The original takes its text from the song.
+func (s *Storage) Translations(ctx context.Context, id int) (string, []models.Translation, error) {
+	const op = "storage.postgres.Translations"
+
+	query := fmt.Sprintf(`
+		SELECT COALESCE(s.lyrics, '') AS lyrics, l.lang, l.original, l.lyrics
+		FROM %s s
+		LEFT JOIN %s l ON l.song_id = s.id
+		WHERE s.id = $1
+		ORDER BY l.original DESC, l.lang
+	`, songsTable, songLyricsTable,
+	)
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var text string
+	translations := []models.Translation{}
+	found := false
+
+	for rows.Next() {
+		var lang, translated sql.NullString
+		var original sql.NullBool
+
+		if err := rows.Scan(&text, &lang, &original, &translated); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		found = true
+
+		if !lang.Valid {
+			continue
+		}
+
+		t := models.Translation{
+			Lang:     lang.String,
+			Original: original.Bool,
+			Text:     translated.String,
+		}
+		if t.Original {
+			t.Text = text
+		}
+
+		translations = append(translations, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !found {
+		return "", nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	return text, translations, nil
+}
+
+// SaveTranslation creates or replaces the lyrics of song id in t.Lang.
+//
+// A translation cannot overwrite the original. Saving the original marks
+// t.Lang as the language of the song lyrics and, when t.Text is set, also
+// replaces them, dropping synced lyrics as PATCH does, and stores detected
+// and fp as their language and fingerprint. A song has at most one
+// original; both conflicts are reported as storage.ErrOriginalConflict.
+func (s *Storage) SaveTranslation(ctx context.Context, id int, t models.Translation, detected models.DetectedLanguage, fp models.Fingerprint) error {
+	const op = "storage.postgres.SaveTranslation"
+
+	if !t.Original {
+		query := fmt.Sprintf(`
+			INSERT INTO %s (song_id, lang, lyrics)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (song_id, lang) DO UPDATE
+			SET lyrics = EXCLUDED.lyrics, updated_at = NOW()
+			WHERE NOT %s.original
+		`, songLyricsTable, songLyricsTable,
+		)
+
+		result, err := s.db.ExecContext(ctx, query, id, t.Lang, t.Text)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, translationError(err))
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("%s: %w", op, storage.ErrOriginalConflict)
+		}
+
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if t.Text != "" {
+		query := fmt.Sprintf(`
+			WITH cleared AS (DELETE FROM %s WHERE song_id = $6)
+			UPDATE %s
+			SET lyrics = $1, language = $2, language_confidence = $3, lyrics_minhash = $4, lyrics_bands = $5
+			WHERE id = $6
+		`, lyricLinesTable, songsTable,
+		)
+
+		language, confidence := languageValues(detected)
+		minHash, bands := fingerprintValues(&fp)
+
+		result, err := tx.ExecContext(ctx, query, t.Text, language, confidence, minHash, bands, id)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (song_id, lang, original)
+		VALUES ($1, $2, TRUE)
+		ON CONFLICT (song_id, lang) DO UPDATE
+		SET original = TRUE, lyrics = NULL, updated_at = NOW()
+	`, songLyricsTable,
+	)
+
+	if _, err := tx.ExecContext(ctx, query, id, t.Lang); err != nil {
+		return fmt.Errorf("%s: %w", op, translationError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteTranslation deletes the lyrics of song id in lang. Deleting the
+// original only forgets its language; the song keeps its lyrics.
+func (s *Storage) DeleteTranslation(ctx context.Context, id int, lang string) error {
+	const op = "storage.postgres.DeleteTranslation"
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE song_id = $1 AND lang = $2`, songLyricsTable)
+
+	result, err := s.db.ExecContext(ctx, query, id, lang)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTranslationNotFound)
+	}
+
+	return nil
+}
+
+func translationError(err error) error {
+	if pgErr, ok := err.(*pq.Error); ok {
+		switch pgErr.Code {
+		case "23503":
+			return storage.ErrSongNotFound
+		case "23505":
+			return storage.ErrOriginalConflict
+		}
+	}
+
+	return err
+}
@@ -3,6 +3,12 @@ package storage
 import "errors"
 
 var (
-	ErrSongExists   = errors.New("exists")
-	ErrSongNotFound = errors.New("song not found")
+	ErrSongExists    = errors.New("exists")
+	ErrSongNotFound  = errors.New("song not found")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrKeyNotFound   = errors.New("api key not found")
+
+	ErrTranslationNotFound = errors.New("lyrics in this language not found")
+	ErrOriginalConflict    = errors.New("original lyrics conflict")
+	ErrChordsNotFound      = errors.New("chord sheet not found")
 )
@@ -0,0 +1,9 @@
+DROP TABLE song_revisions;
+
+DROP INDEX songs_lyrics_bands_idx;
+DROP INDEX songs_canonical_key_idx;
+
+ALTER TABLE songs
+    DROP COLUMN lyrics_bands,
+    DROP COLUMN lyrics_minhash,
+    DROP COLUMN canonical_key;
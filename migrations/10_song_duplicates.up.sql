@@ -0,0 +1,21 @@
+ALTER TABLE songs
+    ADD COLUMN canonical_key TEXT,
+    ADD COLUMN lyrics_minhash BIGINT[],
+    ADD COLUMN lyrics_bands BIGINT[];
+
+CREATE INDEX songs_canonical_key_idx ON songs (canonical_key);
+CREATE INDEX songs_lyrics_bands_idx ON songs USING GIN (lyrics_bands);
+
+-- Revisions outlive their songs: a song merged into another is deleted,
+-- but its last state stays here.
+CREATE TABLE song_revisions (
+    id SERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('merge', 'merged_into')),
+    related_song_id INTEGER,
+    actor TEXT NOT NULL DEFAULT '',
+    snapshot JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX song_revisions_song_id_idx ON song_revisions (song_id, id);
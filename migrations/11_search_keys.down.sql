@@ -0,0 +1,10 @@
+DROP INDEX songs_search_idx;
+DROP INDEX songs_song_key_idx;
+DROP INDEX songs_group_key_idx;
+
+ALTER TABLE songs
+    DROP COLUMN search,
+    DROP COLUMN song_key,
+    DROP COLUMN group_key;
+
+DROP FUNCTION search_key(TEXT);
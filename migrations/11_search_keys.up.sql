@@ -0,0 +1,47 @@
+-- search_key reduces a name or text to a Latin skeleton that Cyrillic and
+-- the common Latin spellings of it share, so "Кино" and "Kino", "Цой" and
+-- "Tsoy" or "Земфира" and "Zemfira" get the same key. Letters whose
+-- spelling varies between schemes fold together: й, и, ы, y and j become
+-- i, ц and ts become c, х and kh become h, ё becomes e, and doubled
+-- letters collapse. г is g in Russian but h in Ukrainian romanization
+-- ("Гуцул" is "Hutsul"), so g and h fold together as h as well.
+-- Everything but letters and digits separates words.
+CREATE FUNCTION search_key(value TEXT) RETURNS TEXT
+    LANGUAGE SQL IMMUTABLE PARALLEL SAFE STRICT
+AS $$
+SELECT trim(regexp_replace(
+    regexp_replace(
+        translate(
+            replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(
+            replace(replace(
+                translate(
+                    replace(replace(replace(replace(replace(replace(replace(replace(replace(replace(
+                        -- lower() leaves Cyrillic as is under the C locale.
+                        lower(translate(value,
+                            'АБВГҐДЕЁЖЗИЙІЇЄКЛМНОПРСТУФХЦЧШЩЪЫЬЭЮЯ',
+                            'абвгґдеёжзийіїєклмнопрстуфхцчшщъыьэюя')),
+                        'щ', 'shch'), 'ж', 'zh'), 'ч', 'ch'), 'ш', 'sh'), 'ю', 'yu'), 'я', 'ya'),
+                        'є', 'ye'), 'ї', 'yi'), 'ц', 'ts'), 'х', 'kh'),
+                    'абвгґдеёзийіклмнопрстуфыэъь',
+                    'abvggdeeziiiklmnoprstufie'
+                ),
+            'shch', 'sch'), 'tch', 'ch'), 'kh', 'h'), 'ts', 'c'), 'tz', 'c'), 'ph', 'f'), 'ck', 'k'),
+            'x', 'ks'), 'w', 'v'), 'q', 'k'), 'j', 'i'), 'y', 'i'),
+            'áàâäãåāéèêëēíìîïóòôöõøúùûüýÿçñßg',
+            'aaaaaaaeeeeeiiiioooooouuuuiicnsh'
+        ),
+        '(.)\1+', '\1', 'g'),
+    '[^[:alnum:]]+', ' ', 'g'))
+$$;
+
+-- Adding the columns computes them for every existing song.
+ALTER TABLE songs
+    ADD COLUMN group_key TEXT GENERATED ALWAYS AS (search_key("group")) STORED,
+    ADD COLUMN song_key TEXT GENERATED ALWAYS AS (search_key(song)) STORED,
+    ADD COLUMN search TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', search_key("group") || ' ' || search_key(song) || ' ' || coalesce(search_key(lyrics), ''))
+    ) STORED;
+
+CREATE INDEX songs_group_key_idx ON songs (group_key);
+CREATE INDEX songs_song_key_idx ON songs (song_key);
+CREATE INDEX songs_search_idx ON songs USING GIN (search);
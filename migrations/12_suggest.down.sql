@@ -0,0 +1,7 @@
+DROP TABLE song_views;
+
+DROP INDEX songs_song_key_trgm_idx;
+DROP INDEX songs_group_key_trgm_idx;
+
+-- pg_trgm is left in place: it may have been installed before this
+-- migration or be used by other objects.
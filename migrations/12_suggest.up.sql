@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX songs_group_key_trgm_idx ON songs USING GIN (group_key gin_trgm_ops);
+CREATE INDEX songs_song_key_trgm_idx ON songs USING GIN (song_key gin_trgm_ops);
+
+-- Views are kept apart from songs, so counting them does not rewrite the
+-- rows of songs and their indexes.
+CREATE TABLE song_views (
+    song_id INTEGER PRIMARY KEY REFERENCES songs (id) ON DELETE CASCADE,
+    views BIGINT NOT NULL DEFAULT 0 CHECK (views >= 0)
+);
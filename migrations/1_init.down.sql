@@ -1 +1 @@
-DROP TABLE songs
\ No newline at end of file
+DROP TABLE songs;
\ No newline at end of file
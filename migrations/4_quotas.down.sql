@@ -0,0 +1 @@
+DROP TABLE quotas;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE quotas (
+    client TEXT NOT NULL,
+    class TEXT NOT NULL,
+    day DATE NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (client, class, day)
+);
@@ -0,0 +1 @@
+DROP TABLE song_lyric_lines;
\ No newline at end of file
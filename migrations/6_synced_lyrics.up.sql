@@ -0,0 +1,8 @@
+CREATE TABLE song_lyric_lines (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    offset_ms INTEGER NOT NULL CHECK (offset_ms >= 0),
+    line TEXT NOT NULL,
+
+    PRIMARY KEY (song_id, position)
+);
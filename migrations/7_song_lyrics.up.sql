@@ -0,0 +1,13 @@
+CREATE TABLE song_lyrics (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    lang TEXT NOT NULL,
+    original BOOLEAN NOT NULL DEFAULT FALSE,
+    -- NULL for the original, whose text is songs.lyrics.
+    lyrics TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (song_id, lang),
+    CHECK (original OR lyrics IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX song_lyrics_original_idx ON song_lyrics (song_id) WHERE original;
@@ -0,0 +1 @@
+DROP TABLE song_chords;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX songs_language_idx;
+
+ALTER TABLE songs
+    DROP COLUMN language_confidence,
+    DROP COLUMN language;
@@ -0,0 +1,5 @@
+ALTER TABLE songs
+    ADD COLUMN language TEXT,
+    ADD COLUMN language_confidence REAL CHECK (language_confidence BETWEEN 0 AND 1);
+
+CREATE INDEX songs_language_idx ON songs (language);
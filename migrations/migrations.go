@@ -0,0 +1,7 @@
+// Package migrations embeds the SQL migrations so that both binaries carry them.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
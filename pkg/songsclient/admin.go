@@ -0,0 +1,92 @@
+package songsclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+)
+
+type (
+	// TokenPair is returned by Login and Refresh.
+	TokenPair = models.TokenPair
+	// APIKeyInfo describes an API key without its secret.
+	APIKeyInfo = models.APIKey
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// Login exchanges a user name and password for an access and refresh token.
+// Pass the access token to BearerToken to authenticate later calls.
+func (c *Client) Login(ctx context.Context, username, password string) (TokenPair, error) {
+	var pair TokenPair
+	err := c.do(ctx, http.MethodPost, "/auth/login", nil, loginRequest{Username: username, Password: password}, &pair)
+
+	return pair, err
+}
+
+// Refresh exchanges a refresh token for a new token pair. The old refresh
+// token is revoked.
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	var pair TokenPair
+	err := c.do(ctx, http.MethodPost, "/auth/refresh", nil, refreshRequest{RefreshToken: refreshToken}, &pair)
+
+	return pair, err
+}
+
+// Logout revokes the access token the client authenticates with and, when
+// given, refreshToken.
+func (c *Client) Logout(ctx context.Context, refreshToken string) error {
+	return c.do(ctx, http.MethodPost, "/auth/logout", nil, refreshRequest{RefreshToken: refreshToken}, nil)
+}
+
+// CreateAPIKeyRequest describes a new API key. ExpiresAt and AllowedIPs are optional.
+type CreateAPIKeyRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	AllowedIPs []string   `json:"allowedIps,omitempty"`
+}
+
+type createAPIKeyResponse struct {
+	Key    string     `json:"key"`
+	APIKey APIKeyInfo `json:"apiKey"`
+}
+
+type apiKeysResponse struct {
+	APIKeys []APIKeyInfo `json:"apiKeys"`
+}
+
+// CreateAPIKey creates an API key and returns it with its secret. The
+// secret cannot be retrieved again. The server ignores Idempotency-Key
+// here, as it would have to store the secret to replay the response.
+func (c *Client) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest, opts ...CallOption) (APIKeyInfo, string, error) {
+	var resp createAPIKeyResponse
+	if err := c.do(ctx, http.MethodPost, "/admin/api-keys", nil, req, &resp, opts...); err != nil {
+		return APIKeyInfo{}, "", err
+	}
+
+	return resp.APIKey, resp.Key, nil
+}
+
+func (c *Client) APIKeys(ctx context.Context) ([]APIKeyInfo, error) {
+	var resp apiKeysResponse
+	if err := c.do(ctx, http.MethodGet, "/admin/api-keys", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.APIKeys, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int, opts ...CallOption) error {
+	return c.do(ctx, http.MethodDelete, "/admin/api-keys/"+strconv.Itoa(id), nil, nil, nil, opts...)
+}
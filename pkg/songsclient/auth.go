@@ -0,0 +1,36 @@
+package songsclient
+
+import "net/http"
+
+// Auth adds credentials to an outgoing request.
+type Auth interface {
+	Apply(req *http.Request)
+}
+
+// AuthFunc adapts a function to Auth.
+type AuthFunc func(req *http.Request)
+
+func (f AuthFunc) Apply(req *http.Request) {
+	f(req)
+}
+
+// BasicAuth authenticates with a user name and password.
+func BasicAuth(username, password string) Auth {
+	return AuthFunc(func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	})
+}
+
+// BearerToken authenticates with an access token obtained from Login or Refresh.
+func BearerToken(token string) Auth {
+	return AuthFunc(func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// APIKey authenticates with an API key.
+func APIKey(key string) Auth {
+	return AuthFunc(func(req *http.Request) {
+		req.Header.Set("X-API-Key", key)
+	})
+}
@@ -0,0 +1,137 @@
+// Package songsclient is a Go client for the songs library API.
+//
+// Every call takes a context, authentication is pluggable through Auth and
+// API errors are returned as *Error values that match ErrSongNotFound,
+// ErrSongExists and the other sentinel errors with errors.Is.
+package songsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+	auth    Auth
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client, which has a 30 second timeout.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// WithAuth sets the credentials sent with every request.
+func WithAuth(a Auth) Option {
+	return func(c *Client) {
+		c.auth = a
+	}
+}
+
+// New returns a client for the API served at baseURL, e.g. http://localhost:8080.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("songsclient: invalid base url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("songsclient: base url must be http or https, got %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: defaultTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// CallOption adjusts a single request.
+type CallOption func(*http.Request)
+
+// WithIdempotencyKey sends key in the Idempotency-Key header, so the call
+// can be retried without applying it twice.
+func WithIdempotencyKey(key string) CallOption {
+	return func(req *http.Request) {
+		req.Header.Set("Idempotency-Key", key)
+	}
+}
+
+// do sends in as JSON and decodes a successful JSON answer into out.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}, opts ...CallOption) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("songsclient: encode request: %w", err)
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("songsclient: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.auth != nil {
+		c.auth.Apply(req)
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("songsclient: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("songsclient: read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newError(resp, path, data)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("songsclient: decode response: %w", err)
+	}
+
+	return nil
+}
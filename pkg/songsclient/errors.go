@@ -0,0 +1,127 @@
+package songsclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSongNotFound        = errors.New("song not found")
+	ErrSongExists          = errors.New("song already exists")
+	ErrKeyNotFound         = errors.New("api key not found")
+	ErrNotSynced           = errors.New("lyrics are not synced")
+	ErrTranslationNotFound = errors.New("translation not found")
+	ErrChordsNotFound      = errors.New("chord sheet not found")
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrConflict            = errors.New("conflict")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrServerError         = errors.New("server error")
+	ErrUnexpectedCode      = errors.New("unexpected status code")
+)
+
+// Error is a non-2xx answer of the API. Use errors.Is with the package
+// sentinel errors to check its kind.
+type Error struct {
+	StatusCode int
+	// Code is the stable error code of the problem response, e.g. "song_exists".
+	Code    string
+	Message string
+	// RetryAfter is set for rate limited requests.
+	RetryAfter time.Duration
+
+	kind error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("songsclient: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// problem is the application/problem+json error body of the API.
+type problem struct {
+	Title  string `json:"title"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func newError(resp *http.Response, path string, body []byte) *Error {
+	var payload problem
+	if json.Unmarshal(body, &payload) != nil || payload.Code == "" {
+		payload = problem{Detail: strings.TrimSpace(string(body))}
+	}
+
+	message := payload.Detail
+	if message == "" {
+		message = payload.Title
+	}
+	for _, fe := range payload.Errors {
+		message += "; " + fe.Message
+	}
+
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Code:       payload.Code,
+		Message:    message,
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	e.kind = errorKind(resp.StatusCode, payload.Code, path)
+
+	return e
+}
+
+// errorKind picks the sentinel for a response: by problem code when the
+// server sent a known one, by status code otherwise.
+func errorKind(status int, code, path string) error {
+	switch code {
+	case "song_exists":
+		return ErrSongExists
+	case "song_not_found":
+		return ErrSongNotFound
+	case "api_key_not_found":
+		return ErrKeyNotFound
+	case "lyrics_not_synced":
+		return ErrNotSynced
+	case "translation_not_found":
+		return ErrTranslationNotFound
+	case "chords_not_found":
+		return ErrChordsNotFound
+	}
+
+	switch {
+	case status == http.StatusNotFound && strings.HasPrefix(path, "/admin/api-keys"):
+		return ErrKeyNotFound
+	case status == http.StatusNotFound:
+		return ErrSongNotFound
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return ErrBadRequest
+	case status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusConflict:
+		return ErrConflict
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500:
+		return ErrServerError
+	default:
+		return ErrUnexpectedCode
+	}
+}
@@ -0,0 +1,79 @@
+package songsclient
+
+import "context"
+
+// SongIterator walks every song matching a filter, fetching one page at a
+// time:
+//
+//	it := client.Songs(filter, 50)
+//	for it.Next(ctx) {
+//		song := it.Song()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SongIterator struct {
+	client  *Client
+	filter  Filter
+	perPage int
+
+	page    int
+	buf     []Song
+	current Song
+	done    bool
+	err     error
+}
+
+// Songs returns an iterator over the songs matching filter. A perPage of 0
+// uses the server default.
+func (c *Client) Songs(filter Filter, perPage int) *SongIterator {
+	return &SongIterator{
+		client:  c,
+		filter:  filter,
+		perPage: perPage,
+	}
+}
+
+// Next advances to the next song, fetching the next page when needed. It
+// returns false when there are no more songs or a request failed.
+func (it *SongIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	for len(it.buf) == 0 {
+		if it.done {
+			return false
+		}
+
+		it.page++
+
+		songs, err := it.client.ListSongs(ctx, it.filter, it.page, it.perPage)
+		if err != nil {
+			it.err = err
+
+			return false
+		}
+
+		// A short page is the last one; the server does not report the total.
+		if len(songs) == 0 || (it.perPage > 0 && len(songs) < it.perPage) {
+			it.done = true
+		}
+
+		it.buf = songs
+	}
+
+	it.current, it.buf = it.buf[0], it.buf[1:]
+
+	return true
+}
+
+// Song returns the song Next advanced to.
+func (it *SongIterator) Song() Song {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *SongIterator) Err() error {
+	return it.err
+}
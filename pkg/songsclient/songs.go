@@ -0,0 +1,489 @@
+package songsclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"effective_mobile/internal/domain/models"
+)
+
+type (
+	// Song is a song of the library as returned by the API.
+	Song = models.SongData
+	// SongUpdate holds the fields to change; nil fields are left as is.
+	SongUpdate = models.UpdateSongData
+	// Section is a typed part of the lyrics: a verse, chorus, bridge etc.
+	Section = models.Section
+	// TimedLine is a line of synced lyrics with its start offset.
+	TimedLine = models.TimedLine
+	// ActiveLine is the synced line being sung at a playback offset.
+	ActiveLine = models.ActiveLine
+	// Translation is the lyrics of a song in one language.
+	Translation = models.Translation
+	// VerseText is a verse with the language it was read in.
+	VerseText = models.VerseText
+	// ChordSheet is a song with chords, split into sections like its lyrics.
+	ChordSheet = models.ChordSheet
+	// ChordSection is a section of a chord sheet.
+	ChordSection = models.ChordSection
+	// ChordLine is a line of lyrics with the chords played over it.
+	ChordLine = models.ChordLine
+	// ChordPosition is a chord and the character of the line it starts at.
+	ChordPosition = models.ChordPosition
+	// LyricsStats describes the lyrics of a song: counts, times, top words.
+	LyricsStats = models.LyricsStats
+	// CatalogStats describes the lyrics of the songs matching a filter.
+	CatalogStats = models.CatalogStats
+	// ArtistStats describes the lyrics of the songs of one group.
+	ArtistStats = models.ArtistStats
+	// YearStats describes the lyrics of the songs released in a year.
+	YearStats = models.YearStats
+	// WordCount is a word and how many times it occurs.
+	WordCount = models.WordCount
+	// SongRef identifies a song by its ID and names.
+	SongRef = models.SongRef
+	// DuplicatePair is two songs that are likely the same.
+	DuplicatePair = models.DuplicatePair
+	// Revision is the state of a song before it was merged.
+	Revision = models.Revision
+	// SongSnapshot is a song as it was at a revision.
+	SongSnapshot = models.SongSnapshot
+	// Suggestion is a group name or song title matching what is typed.
+	Suggestion = models.Suggestion
+	// SimilarSong is a song similar to another, with why it is.
+	SimilarSong = models.SimilarSong
+	// SimilarityReason is a signal a similar song matched by.
+	SimilarityReason = models.SimilarityReason
+)
+
+// Lyrics is the plain text of a song and, when Synced, its timed lines.
+// Languages lists the languages the song has lyrics in, without text.
+type Lyrics struct {
+	Synced    bool          `json:"synced"`
+	Text      string        `json:"text"`
+	Lines     []TimedLine   `json:"lines"`
+	Languages []Translation `json:"languages"`
+}
+
+// Filter narrows the songs returned by ListSongs, Songs and ExportSongs.
+// Empty fields match every song; ReleaseDate is in DD.MM.YYYY format and
+// Language is the BCP-47 language detected in the lyrics. Group and Song
+// match names spelled in Cyrillic or Latin, and Query searches names and
+// lyrics, best matches first.
+type Filter struct {
+	Group       string
+	Song        string
+	ReleaseDate string
+	Language    string
+	Query       string
+}
+
+func (f Filter) query() url.Values {
+	q := url.Values{}
+	if f.Group != "" {
+		q.Set("group", f.Group)
+	}
+	if f.Song != "" {
+		q.Set("song", f.Song)
+	}
+	if f.ReleaseDate != "" {
+		q.Set("releaseDate", f.ReleaseDate)
+	}
+	if f.Language != "" {
+		q.Set("language", f.Language)
+	}
+	if f.Query != "" {
+		q.Set("q", f.Query)
+	}
+
+	return q
+}
+
+type addSongRequest struct {
+	Group string `json:"group"`
+	Song  string `json:"song"`
+}
+
+type addSongResponse struct {
+	ID int `json:"id"`
+}
+
+type songsResponse struct {
+	Songs []Song `json:"songs"`
+}
+
+type textResponse struct {
+	Text string `json:"text"`
+}
+
+type sectionsResponse struct {
+	Sections []Section `json:"sections"`
+}
+
+type translationRequest struct {
+	Text     string `json:"text,omitempty"`
+	Original bool   `json:"original,omitempty"`
+}
+
+type translationResponse struct {
+	Warning string `json:"warning"`
+}
+
+type syncedLyricsRequest struct {
+	LRC string `json:"lrc"`
+}
+
+type duplicatesResponse struct {
+	Duplicates []DuplicatePair `json:"duplicates"`
+}
+
+type mergeRequest struct {
+	Into int `json:"into"`
+}
+
+type mergeResponse struct {
+	Song Song `json:"song"`
+}
+
+type revisionsResponse struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+type suggestResponse struct {
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+type similarResponse struct {
+	Similar []SimilarSong `json:"similar"`
+}
+
+type chordsRequest struct {
+	ChordPro string `json:"chordpro"`
+}
+
+// ChordOptions selects how Chords shows a chord sheet. A nil Capo keeps the
+// capo of the sheet and a Verse of 0 returns every section.
+type ChordOptions struct {
+	Transpose int
+	Capo      *int
+	Verse     int
+}
+
+func (o ChordOptions) query() url.Values {
+	q := url.Values{}
+	if o.Transpose != 0 {
+		q.Set("transpose", strconv.Itoa(o.Transpose))
+	}
+	if o.Capo != nil {
+		q.Set("capo", strconv.Itoa(*o.Capo))
+	}
+	if o.Verse > 0 {
+		q.Set("verse", strconv.Itoa(o.Verse))
+	}
+
+	return q
+}
+
+// AddSong adds a song and returns its ID. Details are fetched by the
+// service from the external music API.
+func (c *Client) AddSong(ctx context.Context, group, song string, opts ...CallOption) (int, error) {
+	var resp addSongResponse
+	if err := c.do(ctx, http.MethodPost, "/songs", nil, addSongRequest{Group: group, Song: song}, &resp, opts...); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+// ListSongs returns a single page of songs. Pages start at 1 and a perPage
+// of 0 uses the server default. A page past the end is empty, not an error.
+func (c *Client) ListSongs(ctx context.Context, filter Filter, page, perPage int) ([]Song, error) {
+	q := filter.query()
+	if page > 0 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if perPage > 0 {
+		q.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	var resp songsResponse
+	err := c.do(ctx, http.MethodGet, "/songs", q, nil, &resp)
+	if errors.Is(err, ErrSongNotFound) {
+		return []Song{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Songs, nil
+}
+
+// Verse returns the text of a verse of a song, counted from 1. Choruses and
+// other sections are not counted.
+func (c *Client) Verse(ctx context.Context, id, verse int) (string, error) {
+	q := url.Values{"verse": {strconv.Itoa(verse)}}
+
+	var resp textResponse
+	if err := c.do(ctx, http.MethodGet, songPath(id), q, nil, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.Text, nil
+}
+
+// VerseIn returns a verse of a song read from its lyrics in lang, numbered
+// like the verses of the original. Warning is set when the translation has
+// a different number of verses. It fails with ErrTranslationNotFound if the
+// song has no lyrics in lang.
+func (c *Client) VerseIn(ctx context.Context, id, verse int, lang string) (VerseText, error) {
+	q := url.Values{"verse": {strconv.Itoa(verse)}, "lang": {lang}}
+
+	var resp VerseText
+	if err := c.do(ctx, http.MethodGet, songPath(id), q, nil, &resp); err != nil {
+		return VerseText{}, err
+	}
+
+	return resp, nil
+}
+
+// Translation returns the lyrics of a song in lang.
+func (c *Client) Translation(ctx context.Context, id int, lang string) (Translation, error) {
+	var resp Translation
+	if err := c.do(ctx, http.MethodGet, translationPath(id, lang), nil, nil, &resp); err != nil {
+		return Translation{}, err
+	}
+
+	return resp, nil
+}
+
+// SaveTranslation creates or replaces the lyrics of a song in lang. With
+// original set, lang becomes the language of the song lyrics and text, if
+// not empty, replaces them. The returned warning is set when the verses of
+// a translation do not line up with the original's.
+func (c *Client) SaveTranslation(ctx context.Context, id int, lang, text string, original bool, opts ...CallOption) (string, error) {
+	var resp translationResponse
+	req := translationRequest{Text: text, Original: original}
+	if err := c.do(ctx, http.MethodPut, translationPath(id, lang), nil, req, &resp, opts...); err != nil {
+		return "", err
+	}
+
+	return resp.Warning, nil
+}
+
+// DeleteTranslation deletes the lyrics of a song in lang.
+func (c *Client) DeleteTranslation(ctx context.Context, id int, lang string) error {
+	return c.do(ctx, http.MethodDelete, translationPath(id, lang), nil, nil, nil)
+}
+
+// Sections returns the lyrics of a song split into sections, only those of
+// sectionType ("verse", "chorus", ...) when it is not empty.
+func (c *Client) Sections(ctx context.Context, id int, sectionType string) ([]Section, error) {
+	q := url.Values{}
+	if sectionType != "" {
+		q.Set("section", sectionType)
+	}
+
+	var resp sectionsResponse
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/sections", q, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Sections, nil
+}
+
+// Lyrics returns the lyrics of a song with its timed lines, if synced.
+func (c *Client) Lyrics(ctx context.Context, id int) (Lyrics, error) {
+	var resp Lyrics
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/lyrics", nil, nil, &resp); err != nil {
+		return Lyrics{}, err
+	}
+
+	return resp, nil
+}
+
+// SetLRC uploads time-synced lyrics in LRC format. They replace the plain
+// lyrics of the song as well.
+func (c *Client) SetLRC(ctx context.Context, id int, lrc string, opts ...CallOption) error {
+	return c.do(ctx, http.MethodPut, songPath(id)+"/lyrics", nil, syncedLyricsRequest{LRC: lrc}, nil, opts...)
+}
+
+// ActiveLine returns the synced line being sung offset into the song. It
+// fails with ErrNotSynced for songs without synced lyrics.
+func (c *Client) ActiveLine(ctx context.Context, id int, offset time.Duration) (ActiveLine, error) {
+	q := url.Values{}
+	q.Set("offset", strconv.FormatInt(offset.Milliseconds(), 10))
+
+	var resp ActiveLine
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/lyrics/line", q, nil, &resp); err != nil {
+		return ActiveLine{}, err
+	}
+
+	return resp, nil
+}
+
+// Chords returns the chord sheet of a song transposed and for the capo of
+// opts. It fails with ErrChordsNotFound if the song has none.
+func (c *Client) Chords(ctx context.Context, id int, opts ChordOptions) (ChordSheet, error) {
+	var resp ChordSheet
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/chords", opts.query(), nil, &resp); err != nil {
+		return ChordSheet{}, err
+	}
+
+	return resp, nil
+}
+
+// SaveChords creates or replaces the chord sheet of a song from ChordPro.
+func (c *Client) SaveChords(ctx context.Context, id int, chordPro string, opts ...CallOption) error {
+	return c.do(ctx, http.MethodPut, songPath(id)+"/chords", nil, chordsRequest{ChordPro: chordPro}, nil, opts...)
+}
+
+// DeleteChords deletes the chord sheet of a song.
+func (c *Client) DeleteChords(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, songPath(id)+"/chords", nil, nil, nil)
+}
+
+// Stats returns statistics of the lyrics of a song, of its lyrics in lang
+// when lang is not empty, with the top most frequent words. A top of 0 uses
+// the server default.
+func (c *Client) Stats(ctx context.Context, id int, lang string, top int) (LyricsStats, error) {
+	q := url.Values{}
+	if lang != "" {
+		q.Set("lang", lang)
+	}
+	if top > 0 {
+		q.Set("top", strconv.Itoa(top))
+	}
+
+	var resp LyricsStats
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/stats", q, nil, &resp); err != nil {
+		return LyricsStats{}, err
+	}
+
+	return resp, nil
+}
+
+// CatalogStats returns statistics of the lyrics of the songs matching
+// filter, per group and per release year.
+func (c *Client) CatalogStats(ctx context.Context, filter Filter, top int) (CatalogStats, error) {
+	q := filter.query()
+	if top > 0 {
+		q.Set("top", strconv.Itoa(top))
+	}
+
+	var resp CatalogStats
+	if err := c.do(ctx, http.MethodGet, "/stats", q, nil, &resp); err != nil {
+		return CatalogStats{}, err
+	}
+
+	return resp, nil
+}
+
+// UpdateSong changes the non-nil fields of upd.
+func (c *Client) UpdateSong(ctx context.Context, id int, upd SongUpdate, opts ...CallOption) error {
+	return c.do(ctx, http.MethodPatch, songPath(id), nil, upd, nil, opts...)
+}
+
+func (c *Client) DeleteSong(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, songPath(id), nil, nil, nil)
+}
+
+// Duplicates returns up to limit pairs of songs that are likely the same,
+// by name or by lyrics at least threshold similar. A threshold or limit of
+// 0 uses the server default.
+func (c *Client) Duplicates(ctx context.Context, threshold float64, limit int) ([]DuplicatePair, error) {
+	q := url.Values{}
+	if threshold > 0 {
+		q.Set("threshold", strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp duplicatesResponse
+	if err := c.do(ctx, http.MethodGet, "/songs/duplicates", q, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Duplicates, nil
+}
+
+// MergeSong merges song id into song into, deletes song id and returns the
+// merged song.
+func (c *Client) MergeSong(ctx context.Context, id, into int, opts ...CallOption) (Song, error) {
+	var resp mergeResponse
+	if err := c.do(ctx, http.MethodPost, songPath(id)+"/merge", nil, mergeRequest{Into: into}, &resp, opts...); err != nil {
+		return Song{}, err
+	}
+
+	return resp.Song, nil
+}
+
+// Revisions returns the revisions of a song, oldest first. They are kept
+// after the song was merged into another.
+func (c *Client) Revisions(ctx context.Context, id int) ([]Revision, error) {
+	var resp revisionsResponse
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/revisions", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Revisions, nil
+}
+
+// Suggest returns up to limit group names or song titles matching q as it
+// is typed. kind is "group", "song" or "all"; an empty kind or a limit of 0
+// uses the server default.
+func (c *Client) Suggest(ctx context.Context, q, kind string, limit int) ([]Suggestion, error) {
+	query := url.Values{}
+	query.Set("q", q)
+	if kind != "" {
+		query.Set("type", kind)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp suggestResponse
+	if err := c.do(ctx, http.MethodGet, "/suggest", query, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Suggestions, nil
+}
+
+// Similar returns up to limit songs most similar to a song, best first. A
+// limit of 0 uses the server default.
+func (c *Client) Similar(ctx context.Context, id, limit int) ([]SimilarSong, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	var resp similarResponse
+	if err := c.do(ctx, http.MethodGet, songPath(id)+"/similar", q, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Similar, nil
+}
+
+// ExportSongs returns every song matching filter in one response.
+func (c *Client) ExportSongs(ctx context.Context, filter Filter) ([]Song, error) {
+	var resp songsResponse
+	if err := c.do(ctx, http.MethodGet, "/songs/export", filter.query(), nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Songs, nil
+}
+
+func songPath(id int) string {
+	return "/songs/" + strconv.Itoa(id)
+}
+
+func translationPath(id int, lang string) string {
+	return songPath(id) + "/lyrics/" + url.PathEscape(lang)
+}
@@ -0,0 +1,26 @@
+// Package swagger embeds the OpenAPI spec so the service can serve it and
+// validate requests against it.
+package swagger
+
+import (
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed swagger.yaml
+var Spec []byte
+
+// Load parses and validates the embedded spec.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(openapi3.NewLoader().Context); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}